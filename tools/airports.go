@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+type airportInfo struct {
+	Lat     float64
+	Lon     float64
+	Country string
+	City    string
+}
+
+// airports is a small built-in reference table of major airports. City is
+// the IATA metropolitan code where one exists, otherwise the airport code.
+var airports = map[string]airportInfo{
+	"ATL": {33.6407, -84.4277, "US", "ATL"},
+	"BOS": {42.3656, -71.0096, "US", "BOS"},
+	"BWI": {39.1774, -76.6684, "US", "WAS"},
+	"CLT": {35.2144, -80.9473, "US", "CLT"},
+	"DCA": {38.8512, -77.0402, "US", "WAS"},
+	"DEN": {39.8561, -104.6737, "US", "DEN"},
+	"DFW": {32.8998, -97.0403, "US", "DFW"},
+	"DTW": {42.2162, -83.3554, "US", "DTT"},
+	"EWR": {40.6895, -74.1745, "US", "NYC"},
+	"FLL": {26.0742, -80.1506, "US", "FLL"},
+	"HOU": {29.6454, -95.2789, "US", "HOU"},
+	"IAD": {38.9531, -77.4565, "US", "WAS"},
+	"IAH": {29.9902, -95.3368, "US", "HOU"},
+	"JFK": {40.6413, -73.7781, "US", "NYC"},
+	"LAS": {36.0840, -115.1537, "US", "LAS"},
+	"LAX": {33.9416, -118.4085, "US", "LAX"},
+	"LGA": {40.7769, -73.8740, "US", "NYC"},
+	"MCO": {28.4312, -81.3081, "US", "ORL"},
+	"MDW": {41.7868, -87.7522, "US", "CHI"},
+	"MIA": {25.7959, -80.2870, "US", "MIA"},
+	"MSP": {44.8848, -93.2223, "US", "MSP"},
+	"OAK": {37.7126, -122.2197, "US", "OAK"},
+	"ORD": {41.9742, -87.9073, "US", "CHI"},
+	"PHL": {39.8744, -75.2424, "US", "PHL"},
+	"PHX": {33.4342, -112.0116, "US", "PHX"},
+	"SEA": {47.4502, -122.3088, "US", "SEA"},
+	"SFO": {37.6213, -122.3790, "US", "SFO"},
+	"SJC": {37.3639, -121.9289, "US", "SJC"},
+	"YUL": {45.4706, -73.7408, "CA", "YMQ"},
+	"YVR": {49.1967, -123.1815, "CA", "YVR"},
+	"YYZ": {43.6777, -79.6248, "CA", "YTO"},
+	"MEX": {19.4361, -99.0719, "MX", "MEX"},
+	"PTY": {9.0714, -79.3835, "PA", "PTY"},
+	"BOG": {4.7016, -74.1469, "CO", "BOG"},
+	"LIM": {-12.0219, -77.1143, "PE", "LIM"},
+	"SCL": {-33.3930, -70.7858, "CL", "SCL"},
+	"EZE": {-34.8222, -58.5358, "AR", "BUE"},
+	"GRU": {-23.4356, -46.4731, "BR", "SAO"},
+	"GIG": {-22.8090, -43.2506, "BR", "RIO"},
+	"LHR": {51.4700, -0.4543, "GB", "LON"},
+	"LGW": {51.1537, -0.1821, "GB", "LON"},
+	"STN": {51.8860, 0.2389, "GB", "LON"},
+	"LTN": {51.8747, -0.3683, "GB", "LON"},
+	"LCY": {51.5048, 0.0495, "GB", "LON"},
+	"DUB": {53.4264, -6.2499, "IE", "DUB"},
+	"CDG": {49.0097, 2.5479, "FR", "PAR"},
+	"ORY": {48.7262, 2.3652, "FR", "PAR"},
+	"AMS": {52.3105, 4.7683, "NL", "AMS"},
+	"BRU": {50.9014, 4.4844, "BE", "BRU"},
+	"FRA": {50.0379, 8.5622, "DE", "FRA"},
+	"MUC": {48.3537, 11.7750, "DE", "MUC"},
+	"ZRH": {47.4582, 8.5555, "CH", "ZRH"},
+	"VIE": {48.1103, 16.5697, "AT", "VIE"},
+	"MAD": {40.4983, -3.5676, "ES", "MAD"},
+	"BCN": {41.2974, 2.0833, "ES", "BCN"},
+	"LIS": {38.7742, -9.1342, "PT", "LIS"},
+	"FCO": {41.8003, 12.2389, "IT", "ROM"},
+	"MXP": {45.6306, 8.7281, "IT", "MIL"},
+	"LIN": {45.4451, 9.2767, "IT", "MIL"},
+	"CPH": {55.6180, 12.6508, "DK", "CPH"},
+	"ARN": {59.6498, 17.9238, "SE", "STO"},
+	"OSL": {60.1976, 11.1004, "NO", "OSL"},
+	"HEL": {60.3172, 24.9633, "FI", "HEL"},
+	"WAW": {52.1657, 20.9671, "PL", "WAW"},
+	"ATH": {37.9364, 23.9445, "GR", "ATH"},
+	"IST": {41.2753, 28.7519, "TR", "IST"},
+	"SAW": {40.8986, 29.3092, "TR", "IST"},
+	"SVO": {55.9726, 37.4146, "RU", "MOW"},
+	"DME": {55.4088, 37.9063, "RU", "MOW"},
+	"DXB": {25.2532, 55.3657, "AE", "DXB"},
+	"AUH": {24.4330, 54.6511, "AE", "AUH"},
+	"DOH": {25.2731, 51.6081, "QA", "DOH"},
+	"CAI": {30.1219, 31.4056, "EG", "CAI"},
+	"CMN": {33.3675, -7.5898, "MA", "CAS"},
+	"ADD": {8.9779, 38.7993, "ET", "ADD"},
+	"NBO": {-1.3192, 36.9278, "KE", "NBO"},
+	"JNB": {-26.1367, 28.2411, "ZA", "JNB"},
+	"DEL": {28.5562, 77.1000, "IN", "DEL"},
+	"BOM": {19.0896, 72.8656, "IN", "BOM"},
+	"BKK": {13.6900, 100.7501, "TH", "BKK"},
+	"KUL": {2.7456, 101.7072, "MY", "KUL"},
+	"SIN": {1.3644, 103.9915, "SG", "SIN"},
+	"CGK": {-6.1256, 106.6559, "ID", "JKT"},
+	"MNL": {14.5086, 121.0194, "PH", "MNL"},
+	"HKG": {22.3080, 113.9185, "HK", "HKG"},
+	"TPE": {25.0797, 121.2342, "TW", "TPE"},
+	"PVG": {31.1443, 121.8083, "CN", "SHA"},
+	"SHA": {31.1979, 121.3363, "CN", "SHA"},
+	"PEK": {40.0799, 116.6031, "CN", "BJS"},
+	"PKX": {39.5098, 116.4105, "CN", "BJS"},
+	"ICN": {37.4602, 126.4407, "KR", "SEL"},
+	"HND": {35.5494, 139.7798, "JP", "TYO"},
+	"NRT": {35.7720, 140.3929, "JP", "TYO"},
+	"SYD": {-33.9399, 151.1753, "AU", "SYD"},
+	"MEL": {-37.6690, 144.8410, "AU", "MEL"},
+	"AKL": {-37.0082, 174.7850, "NZ", "AKL"},
+}
+
+func lookupAirport(code string) (airportInfo, bool) {
+	info, ok := airports[strings.ToUpper(strings.TrimSpace(code))]
+	return info, ok
+}
+
+// metroCode resolves an airport or IATA city code to its metropolitan
+// area, so "JFK", "EWR" and "NYC" all resolve to "NYC". Unknown codes
+// resolve to themselves.
+func metroCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if info, ok := airports[code]; ok {
+		return info.City
+	}
+	return code
+}
+
+const (
+	defaultNearbyRadiusKm = 100
+	maxNearbyAirports     = 3
+)
+
+// nearbyAirports lists other airports in the same metropolitan area or
+// within FLIGHT_NEARBY_RADIUS_KM, closest first.
+func nearbyAirports(code string) []string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	home, ok := lookupAirport(code)
+	if !ok {
+		return nil
+	}
+	radius := float64(envInt("FLIGHT_NEARBY_RADIUS_KM", defaultNearbyRadiusKm))
+
+	type candidate struct {
+		code     string
+		distance float64
+	}
+	var candidates []candidate
+	for other, info := range airports {
+		if other == code {
+			continue
+		}
+		distance := distanceKm(home, info)
+		if info.City == home.City || distance <= radius {
+			candidates = append(candidates, candidate{code: other, distance: distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	nearby := make([]string, 0, maxNearbyAirports)
+	for _, c := range candidates {
+		if len(nearby) == maxNearbyAirports {
+			break
+		}
+		nearby = append(nearby, c.code)
+	}
+	return nearby
+}
+
+// distanceKm is the great-circle distance between two airports.
+func distanceKm(a, b airportInfo) float64 {
+	const earthRadiusKm = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
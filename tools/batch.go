@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+type BatchResult struct {
+	Params SearchParams
+	Query  string
+	Offers []FlightOffer
+	Source string
+	Meta   map[string]interface{}
+	Err    error
+}
+
+// BatchSearch runs independent searches concurrently. All of them share
+// the cached Amadeus token and a single FLIGHT_MAX_CONCURRENCY limit, so a
+// batch never issues more parallel requests than one search would. A
+// failing query only sets its own Err; the returned error is non-nil only
+// when ctx ends before the batch completes.
+func BatchSearch(ctx context.Context, queries []SearchParams) ([]BatchResult, error) {
+	results := make([]BatchResult, len(queries))
+	sem := newLimiter()
+
+	var wg sync.WaitGroup
+	for i, params := range queries {
+		wg.Add(1)
+		go func(i int, params SearchParams) {
+			defer wg.Done()
+			result := BatchResult{Params: params, Query: buildQuery(params)}
+			outcome, err := searchFlightsWithLimiter(ctx, params, sem)
+			if err != nil {
+				result.Err = err
+			} else {
+				result.Offers = outcome.Offers
+				result.Source = outcome.Source
+				result.Meta = outcome.Meta
+			}
+			results[i] = result
+		}(i, params)
+	}
+	wg.Wait()
+
+	return results, ctx.Err()
+}
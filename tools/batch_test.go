@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBatchSearchIsolatesFailures(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	results, err := BatchSearch(context.Background(), []SearchParams{
+		{Origin: "SFO", Destination: "JFK", DepartDate: "2026-03-15"},
+		{Origin: "LAX", Destination: "ORD", DepartDate: "2026-03-15", MileageProgram: "nope"},
+		{Origin: "BOS", Destination: "MIA", DepartDate: "2026-03-16", FlexDays: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	if results[0].Err != nil || len(results[0].Offers) != 3 || results[0].Offers[0].Origin != "SFO" {
+		t.Errorf("query 0: err=%v, %d offers", results[0].Err, len(results[0].Offers))
+	}
+	if ErrorCode(results[1].Err) != ErrorCodeInvalidArgument || results[1].Offers != nil {
+		t.Errorf("query 1: err=%v, %d offers, want invalid argument and no offers", results[1].Err, len(results[1].Offers))
+	}
+	if results[2].Err != nil || len(results[2].Offers) != 9 || results[2].Source != "mock" {
+		t.Errorf("query 2: err=%v, %d offers from %q, want 9 mock offers", results[2].Err, len(results[2].Offers), results[2].Source)
+	}
+	for i, result := range results {
+		if result.Query != buildQuery(result.Params) {
+			t.Errorf("query %d: Query = %q", i, result.Query)
+		}
+	}
+}
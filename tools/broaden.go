@@ -0,0 +1,80 @@
+package tools
+
+import "context"
+
+const broadenFlexDays = 3
+
+type broadenStep struct {
+	name  string
+	apply func(params *SearchParams) bool
+}
+
+// broadenSteps run in this order until min_results is met: allow
+// connections, widen dates to ±broadenFlexDays, then add nearby airports.
+// Each step keeps the previous ones applied.
+var broadenSteps = []broadenStep{
+	{name: "nonstop", apply: func(params *SearchParams) bool {
+		if !params.NonStop {
+			return false
+		}
+		params.NonStop = false
+		return true
+	}},
+	{name: "flex_days", apply: func(params *SearchParams) bool {
+		if params.FlexDays >= broadenFlexDays {
+			return false
+		}
+		params.FlexDays = broadenFlexDays
+		return true
+	}},
+	{name: "nearby_airports", apply: func(params *SearchParams) bool {
+		if params.IncludeNearby {
+			return false
+		}
+		params.IncludeNearby = true
+		return true
+	}},
+}
+
+// broaden re-runs the search with progressively looser constraints while
+// fewer than min_results offers survive the filters. Every broadened
+// search is a superset of the previous one, so its results replace them.
+// Steps draw on the call's request budget and stop once it is spent.
+func (r *searchRun) broaden(ctx context.Context, params SearchParams, offers []FlightOffer) ([]FlightOffer, SearchParams, error) {
+	if params.MinResults <= 0 || len(applyFilters(offers, params)) >= params.MinResults {
+		return offers, params, nil
+	}
+
+	var applied []string
+	exhausted := false
+	for _, step := range broadenSteps {
+		if r.budget.exhausted() {
+			exhausted = true
+			r.budget.skip("min_results")
+			break
+		}
+		if !step.apply(&params) {
+			continue
+		}
+		broadened, err := r.search(ctx, params)
+		if err != nil {
+			return nil, params, err
+		}
+		offers = broadened
+		applied = append(applied, step.name)
+		if len(applyFilters(offers, params)) >= params.MinResults {
+			break
+		}
+	}
+
+	r.relaxed = append(r.relaxed, applied...)
+	r.broadened = map[string]interface{}{
+		"target":  params.MinResults,
+		"applied": applied,
+		"reached": len(applyFilters(offers, params)) >= params.MinResults,
+	}
+	if exhausted {
+		r.broadened["note"] = "stopped early: FLIGHT_MAX_REQUESTS_PER_CALL reached"
+	}
+	return offers, params, nil
+}
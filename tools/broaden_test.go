@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBroadenStopsWhenBudgetIsSpent(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_MAX_REQUESTS_PER_CALL", "1")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "SFO",
+		Destination: "JFK",
+		DepartDate:  "2026-03-15",
+		NonStop:     true,
+		MinResults:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requests := outcome.Meta["requests"].(int); requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+	broadened := outcome.Meta["broadened"].(map[string]interface{})
+	if broadened["reached"] != false {
+		t.Errorf("reached = %v, want false", broadened["reached"])
+	}
+	if applied := broadened["applied"].([]string); len(applied) != 0 {
+		t.Errorf("applied = %v, want none", applied)
+	}
+	if broadened["note"] == nil {
+		t.Error("missing budget note")
+	}
+	skipped := outcome.Meta["trimmed"].(map[string]interface{})["skipped"].([]string)
+	if len(skipped) != 1 || skipped[0] != "min_results" {
+		t.Errorf("skipped = %v, want [min_results]", skipped)
+	}
+}
+
+func TestBroadenReachesTarget(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "SFO",
+		Destination: "JFK",
+		DepartDate:  "2026-03-15",
+		NonStop:     true,
+		MinResults:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broadened := outcome.Meta["broadened"].(map[string]interface{})
+	if broadened["reached"] != true {
+		t.Errorf("broadened = %v, want reached", broadened)
+	}
+	if applied := broadened["applied"].([]string); len(applied) != 1 || applied[0] != "nonstop" {
+		t.Errorf("applied = %v, want [nonstop]", applied)
+	}
+	if len(outcome.Offers) < 3 {
+		t.Errorf("got %d offers, want at least 3", len(outcome.Offers))
+	}
+}
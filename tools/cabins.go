@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+var cabinRank = map[string]int{
+	"ECONOMY":         0,
+	"PREMIUM_ECONOMY": 1,
+	"BUSINESS":        2,
+	"FIRST":           3,
+}
+
+func normalizeCabin(cabin string) string {
+	cabin = strings.ToUpper(strings.TrimSpace(cabin))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(cabin)
+}
+
+// predominantCabin is the cabin flown on most segments; ties go to the
+// lower cabin so a mixed itinerary is never oversold.
+func predominantCabin(segments []Segment) string {
+	counts := map[string]int{}
+	for _, segment := range segments {
+		if segment.Cabin != "" {
+			counts[segment.Cabin]++
+		}
+	}
+
+	best := ""
+	for cabin, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && cabinRank[cabin] < cabinRank[best]) {
+			best = cabin
+		}
+	}
+	return best
+}
+
+// cabinMatches reports whether every segment with fare details is in the
+// requested cabin. It is nil when nothing was requested or the offer has
+// no fare details.
+func cabinMatches(offer FlightOffer, requested string) *bool {
+	requested = normalizeCabin(requested)
+	if requested == "" || offer.Cabin == "" {
+		return nil
+	}
+	matches := true
+	for _, segment := range offer.Segments {
+		if segment.Cabin != "" && segment.Cabin != requested {
+			matches = false
+			break
+		}
+	}
+	return &matches
+}
+
+func cabinWarnings(offers []FlightOffer, requested string) []string {
+	mismatched := 0
+	for _, offer := range offers {
+		if offer.CabinMatchesRequest != nil && !*offer.CabinMatchesRequest {
+			mismatched++
+		}
+	}
+	if mismatched == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d of %d offers are not entirely in the requested %s cabin", mismatched, len(offers), normalizeCabin(requested))}
+}
+
+// addCabinSummary adds meta.by_cabin when offers span more than one
+// predominant cabin: per cabin, the offer count and cheapest fare. A cabin
+// whose fares use mixed currencies reports its count only.
+func addCabinSummary(meta map[string]interface{}, offers []FlightOffer) {
+	groups := map[string][]FlightOffer{}
+	for _, offer := range offers {
+		if offer.Cabin != "" {
+			groups[offer.Cabin] = append(groups[offer.Cabin], offer)
+		}
+	}
+	if len(groups) < 2 {
+		return
+	}
+
+	summary := make(map[string]interface{}, len(groups))
+	for cabin, group := range groups {
+		entry := map[string]interface{}{"count": len(group)}
+		if min, currency, ok := cheapestOffer(group); ok && singleCurrency(group, currency) {
+			entry["cheapest"] = min
+			entry["currency"] = currency
+		}
+		summary[cabin] = entry
+	}
+	meta["by_cabin"] = summary
+}
+
+func singleCurrency(offers []FlightOffer, currency string) bool {
+	for _, offer := range offers {
+		if _, ok := parsePrice(offer.Price); ok && !strings.EqualFold(offer.Currency, currency) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDowngradedCabinWarns(t *testing.T) {
+	economy := strings.Replace(nonstopOffer("1", "AA1", "100.00", "10:00", "11:00"),
+		`"itineraries"`, `"travelerPricings":[{"fareDetailsBySegment":[{"segmentId":"1","cabin":"ECONOMY"}]}],"itineraries"`, 1)
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("travelClass"); got != "BUSINESS" {
+			t.Errorf("travelClass = %q, want BUSINESS", got)
+		}
+		w.Write([]byte(offersBody(economy)))
+	})
+
+	outcome, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01", Cabin: "business"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) != 1 {
+		t.Fatalf("got %d offers, want 1", len(outcome.Offers))
+	}
+	offer := outcome.Offers[0]
+	if offer.Cabin != "ECONOMY" || offer.CabinMatchesRequest == nil || *offer.CabinMatchesRequest {
+		t.Errorf("cabin = %q, cabin_matches_request = %v, want ECONOMY and false", offer.Cabin, offer.CabinMatchesRequest)
+	}
+	warnings, _ := outcome.Meta["warnings"].([]string)
+	if len(warnings) != 1 || warnings[0] != "1 of 1 offers are not entirely in the requested BUSINESS cabin" {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestCabinSummaryFromMixedSet(t *testing.T) {
+	offer := func(price string, cabins ...string) FlightOffer {
+		var segments []Segment
+		for _, cabin := range cabins {
+			segments = append(segments, Segment{Cabin: cabin})
+		}
+		return FlightOffer{Price: price, Currency: "USD", Segments: segments, Cabin: predominantCabin(segments)}
+	}
+	offers := []FlightOffer{
+		offer("300.00", "ECONOMY"),
+		offer("250.00", "ECONOMY", "ECONOMY"),
+		offer("900.00", "BUSINESS", "BUSINESS", "ECONOMY"),
+		offer("1200.00", "BUSINESS"),
+		offer("700.00", "BUSINESS", "ECONOMY"),
+	}
+	if offers[4].Cabin != "ECONOMY" {
+		t.Fatalf("one business and one economy segment: cabin = %s, want the lower ECONOMY", offers[4].Cabin)
+	}
+
+	meta := map[string]interface{}{}
+	addCabinSummary(meta, offers)
+	byCabin := meta["by_cabin"].(map[string]interface{})
+	economy := byCabin["ECONOMY"].(map[string]interface{})
+	business := byCabin["BUSINESS"].(map[string]interface{})
+	if economy["count"] != 3 || economy["cheapest"] != 250.0 || economy["currency"] != "USD" {
+		t.Errorf("ECONOMY = %v, want 3 offers from 250 USD", economy)
+	}
+	if business["count"] != 2 || business["cheapest"] != 900.0 {
+		t.Errorf("BUSINESS = %v, want 2 offers from 900", business)
+	}
+
+	meta = map[string]interface{}{}
+	addCabinSummary(meta, offers[:2])
+	if _, ok := meta["by_cabin"]; ok {
+		t.Errorf("single cabin: by_cabin = %v, want omitted", meta["by_cabin"])
+	}
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+const maxCacheEntries = 256
+
+type cacheEntry struct {
+	offers   []FlightOffer
+	storedAt time.Time
+}
+
+// offerCache keeps the latest raw result per query. Entries are served as
+// cache hits only within FLIGHT_CACHE_TTL, but are retained past that so
+// diff_from_cache can compare a fresh search with the previous one.
+type offerCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+var resultCache = &offerCache{entries: map[string]cacheEntry{}}
+
+func (c *offerCache) get(key string) ([]FlightOffer, bool) {
+	ttl := cacheTTL()
+	if ttl <= 0 {
+		return nil, false
+	}
+
+	entry, ok := c.previous(key)
+	if !ok || time.Since(entry.storedAt) > ttl {
+		return nil, false
+	}
+	return entry.offers, true
+}
+
+func (c *offerCache) previous(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	entry.offers = append([]FlightOffer(nil), entry.offers...)
+	return entry, true
+}
+
+func (c *offerCache) put(key string, offers []FlightOffer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxCacheEntries {
+		c.evictOldest()
+	}
+	c.entries[key] = cacheEntry{offers: append([]FlightOffer(nil), offers...), storedAt: time.Now()}
+}
+
+func (c *offerCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+func cacheTTL() time.Duration {
+	value := os.Getenv("FLIGHT_CACHE_TTL")
+	if value == "" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// diffOffers compares a fresh result with the previously stored one by
+// offer_id.
+func diffOffers(previous cacheEntry, current []FlightOffer) map[string]interface{} {
+	before := make(map[string]FlightOffer, len(previous.offers))
+	for _, offer := range previous.offers {
+		before[offer.OfferID] = offer
+	}
+
+	added := []string{}
+	changed := []map[string]interface{}{}
+	seen := make(map[string]bool, len(current))
+	for _, offer := range current {
+		seen[offer.OfferID] = true
+		old, ok := before[offer.OfferID]
+		if !ok {
+			added = append(added, offer.OfferID)
+			continue
+		}
+		oldPrice, oldOK := parsePrice(old.Price)
+		newPrice, newOK := parsePrice(offer.Price)
+		if oldOK && newOK && oldPrice != newPrice {
+			changed = append(changed, map[string]interface{}{
+				"offer_id":  offer.OfferID,
+				"old_price": oldPrice,
+				"new_price": newPrice,
+				"currency":  offer.Currency,
+			})
+		}
+	}
+
+	removed := []string{}
+	for _, offer := range previous.offers {
+		if !seen[offer.OfferID] {
+			removed = append(removed, offer.OfferID)
+		}
+	}
+
+	return map[string]interface{}{
+		"previous_at":   previous.storedAt.UTC().Format(time.RFC3339),
+		"new":           added,
+		"removed":       removed,
+		"price_changed": changed,
+	}
+}
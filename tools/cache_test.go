@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// freshCache swaps in an empty result cache for the test's duration.
+func freshCache(t *testing.T) {
+	t.Helper()
+	previous := resultCache
+	resultCache = &offerCache{entries: map[string]cacheEntry{}}
+	t.Cleanup(func() { resultCache = previous })
+}
+
+func TestDiffFromCacheAcrossRuns(t *testing.T) {
+	freshCache(t)
+	var calls int32
+	second := offersBody(nonstopOffer("1", "AA1", "120.00", "10:00", "11:00"), nonstopOffer("2", "B61", "90.00", "12:00", "13:00"))
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(sampleOffersBody))
+			return
+		}
+		w.Write([]byte(second))
+	})
+	params := SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01", DiffFromCache: true}
+
+	outcome, err := searchFlights(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := outcome.Meta["diff_from_cache"].(map[string]interface{})
+	if diff["previous_at"] != nil {
+		t.Errorf("first run: diff_from_cache = %v, want previous_at null", diff)
+	}
+	repriced := outcome.Offers[0].OfferID
+
+	outcome, err = searchFlights(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) != 2 {
+		t.Fatalf("second run: %d offers, want 2", len(outcome.Offers))
+	}
+	diff = outcome.Meta["diff_from_cache"].(map[string]interface{})
+	if diff["previous_at"] == nil {
+		t.Error("second run: previous_at missing")
+	}
+	added := diff["new"].([]string)
+	if len(added) != 1 || added[0] == repriced {
+		t.Errorf("new = %v, want the B6 offer only", added)
+	}
+	if removed := diff["removed"].([]string); len(removed) != 0 {
+		t.Errorf("removed = %v, want none", removed)
+	}
+	changed := diff["price_changed"].([]map[string]interface{})
+	if len(changed) != 1 || changed[0]["offer_id"] != repriced || changed[0]["old_price"] != 100.0 || changed[0]["new_price"] != 120.0 {
+		t.Errorf("price_changed = %v, want %s from 100 to 120", changed, repriced)
+	}
+}
+
+func TestTrimmedSearchIsNotCached(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_CACHE_TTL", "10m")
+	freshCache(t)
+	params := SearchParams{Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", FlexDays: 3}
+
+	t.Setenv("FLIGHT_MAX_REQUESTS_PER_CALL", "3")
+	if _, err := searchFlights(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FLIGHT_MAX_REQUESTS_PER_CALL", "")
+	outcome, err := searchFlights(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Meta["cached"] != false || outcome.Meta["requests"] != 7 {
+		t.Errorf("after a trimmed run: cached=%v requests=%v, want a fresh search of all 7 dates", outcome.Meta["cached"], outcome.Meta["requests"])
+	}
+	if len(outcome.Offers) != 21 {
+		t.Errorf("got %d offers, want 21 across 7 dates", len(outcome.Offers))
+	}
+}
+
+func TestCacheHitDoesNotReportTrimmedRequests(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_CACHE_TTL", "10m")
+	freshCache(t)
+	params := SearchParams{Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", FlexDays: 1}
+
+	if _, err := searchFlights(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FLIGHT_MAX_REQUESTS_PER_CALL", "1")
+	outcome, err := searchFlights(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Meta["cached"] != true || outcome.Meta["requests"] != 0 {
+		t.Errorf("cached=%v requests=%v, want a cache hit with no requests", outcome.Meta["cached"], outcome.Meta["requests"])
+	}
+	if trimmed, ok := outcome.Meta["trimmed"]; ok {
+		t.Errorf("cache hit reported trimmed = %v", trimmed)
+	}
+	if len(outcome.Offers) != 9 {
+		t.Errorf("got %d offers, want the cached 9", len(outcome.Offers))
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+const redactedToken = "$AMADEUS_TOKEN"
+
+// debugCurl renders the flight offers request as a cURL command. The
+// request is built with a placeholder token, so the real bearer token never
+// reaches the payload; export AMADEUS_TOKEN before running the command.
+func debugCurl(baseURL string, params SearchParams, req searchRequest) (string, error) {
+	request, err := newOffersRequest(context.Background(), baseURL, redactedToken, params, req)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{"curl", "-sS", shellQuote(request.URL.String())}
+	for _, name := range names {
+		for _, value := range request.Header[name] {
+			if name == "Accept-Encoding" && value == "gzip" {
+				parts = append(parts, "--compressed")
+				continue
+			}
+			header := name + ": " + value
+			if name == "Authorization" {
+				// Double quotes so the shell expands $AMADEUS_TOKEN.
+				parts = append(parts, "-H", `"`+header+`"`)
+				continue
+			}
+			parts = append(parts, "-H", shellQuote(header))
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugCurlRedactsToken(t *testing.T) {
+	server := fakeAmadeus(t, "real-secret-token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleOffersBody))
+	})
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "JFK",
+		Destination: "BOS",
+		DepartDate:  "2026-01-01",
+		DebugCurl:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.DebugCurl) != 1 {
+		t.Fatalf("debug_curl = %v, want one command", outcome.DebugCurl)
+	}
+
+	command := outcome.DebugCurl[0]
+	for _, want := range []string{
+		"'" + server.URL + "/v2/shopping/flight-offers?",
+		"originLocationCode=JFK",
+		`-H "Authorization: Bearer $AMADEUS_TOKEN"`,
+		"--compressed",
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("curl command %q missing %q", command, want)
+		}
+	}
+	if strings.Contains(command, "real-secret-token") {
+		t.Errorf("curl command %q leaks the bearer token", command)
+	}
+}
@@ -0,0 +1,56 @@
+package tools
+
+import "encoding/json"
+
+type amadeusDictionaries struct {
+	Carriers map[string]string
+	Aircraft map[string]string
+}
+
+// parseDictionaries reads the optional dictionaries block of a flight
+// offers response. Enrichment is best effort: anything that is not a
+// string→string mapping is skipped with a warning instead of failing the
+// parse.
+func parseDictionaries(raw json.RawMessage) amadeusDictionaries {
+	dicts := amadeusDictionaries{Carriers: map[string]string{}, Aircraft: map[string]string{}}
+	if len(raw) == 0 || string(raw) == "null" {
+		return dicts
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		logf("ignoring malformed dictionaries: %v", err)
+		return dicts
+	}
+
+	dicts.Carriers = stringDictionary("carriers", sections["carriers"])
+	dicts.Aircraft = stringDictionary("aircraft", sections["aircraft"])
+	return dicts
+}
+
+func stringDictionary(name string, raw json.RawMessage) map[string]string {
+	entries := map[string]string{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return entries
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		logf("ignoring malformed %s dictionary: %v", name, err)
+		return entries
+	}
+
+	skipped := 0
+	for key, value := range values {
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil || text == "" {
+			skipped++
+			continue
+		}
+		entries[key] = text
+	}
+	if skipped > 0 {
+		logf("skipped %d unreadable %s dictionary entries", skipped, name)
+	}
+	return entries
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// captureLog routes Logger into the returned slice for the test's duration.
+func captureLog(t *testing.T) *[]string {
+	t.Helper()
+	var lines []string
+	previous := Logger
+	Logger = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	t.Cleanup(func() { Logger = previous })
+	return &lines
+}
+
+func TestOddDictionariesKeepCoreOffers(t *testing.T) {
+	offer := `{"id":"1","price":{"total":"100.00","currency":"EUR"},"itineraries":[{"segments":[{"carrierCode":"LH","number":"400","aircraft":{"code":"744"},"departure":{"iataCode":"FRA","at":"2026-11-10T10:00:00"},"arrival":{"iataCode":"JFK","at":"2026-11-10T13:00:00"}}]}]}`
+	tests := []struct {
+		name         string
+		dictionaries string
+		airlineName  string
+		logged       string
+	}{
+		{"entry of the wrong type", `{"carriers":{"LH":"LUFTHANSA","UA":42},"aircraft":{"744":"BOEING 747-400"}}`, "LUFTHANSA", "skipped 1 unreadable carriers dictionary entries"},
+		{"section of the wrong type", `{"carriers":["LH"],"aircraft":{"744":"BOEING 747-400"}}`, "", "malformed carriers dictionary"},
+		{"dictionaries not an object", `"truncated"`, "", "malformed dictionaries"},
+	}
+	for _, tt := range tests {
+		lines := captureLog(t)
+		body := `{"data":[` + offer + `],"dictionaries":` + tt.dictionaries + `}`
+
+		offers, err := parseAmadeusOffers([]byte(body))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(offers) != 1 || offers[0].FlightNumber != "LH400" || offers[0].Price != "100.00" {
+			t.Fatalf("%s: offers = %+v, want the LH400 offer", tt.name, offers)
+		}
+		if offers[0].AirlineName != tt.airlineName {
+			t.Errorf("%s: airline_name = %q, want %q", tt.name, offers[0].AirlineName, tt.airlineName)
+		}
+		if !strings.Contains(strings.Join(*lines, "\n"), tt.logged) {
+			t.Errorf("%s: log = %q, want a line containing %q", tt.name, *lines, tt.logged)
+		}
+	}
+}
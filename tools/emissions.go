@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type co2Emission struct {
+	Weight     float64 `json:"weight"`
+	WeightUnit string  `json:"weightUnit"`
+	Cabin      string  `json:"cabin"`
+}
+
+// emissionsKg reads the first usable co2Emissions entry of a segment.
+func emissionsKg(entries []co2Emission) *float64 {
+	for _, entry := range entries {
+		if entry.Weight <= 0 {
+			continue
+		}
+		switch strings.ToUpper(entry.WeightUnit) {
+		case "", "KG":
+			kg := entry.Weight
+			return &kg
+		case "T":
+			kg := entry.Weight * 1000
+			return &kg
+		case "G":
+			kg := entry.Weight / 1000
+			return &kg
+		}
+	}
+	return nil
+}
+
+// totalEmissions sums segment emissions, or returns nil when any segment
+// is missing data so partial figures never look like a full journey.
+func totalEmissions(segments []Segment) *float64 {
+	if len(segments) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, segment := range segments {
+		if segment.CO2Kg == nil {
+			return nil
+		}
+		total += *segment.CO2Kg
+	}
+	return &total
+}
+
+// roundTripEmissions adds the return itinerary to the outbound figure.
+// One-way offers report the outbound figure; a return leg without full
+// emissions data yields nil rather than an understated total.
+func roundTripEmissions(offer FlightOffer) *float64 {
+	if offer.CO2Kg == nil {
+		return nil
+	}
+	total := *offer.CO2Kg
+	if len(offer.ReturnSegments) > 0 {
+		inbound := totalEmissions(offer.ReturnSegments)
+		if inbound == nil {
+			return nil
+		}
+		total += *inbound
+	}
+	return &total
+}
+
+// journeyEmissions is the CO2 the fare pays for: both directions when the
+// round-trip figure is known, otherwise the outbound figure.
+func journeyEmissions(offer FlightOffer) *float64 {
+	if offer.CO2RoundTripKg != nil {
+		return offer.CO2RoundTripKg
+	}
+	return offer.CO2Kg
+}
+
+// carbonPricePerTonne reads CARBON_PRICE_PER_TONNE, taken to be in the
+// requested currency. Zero disables offset estimates.
+func carbonPricePerTonne() float64 {
+	price, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("CARBON_PRICE_PER_TONNE")), 64)
+	if err != nil || price <= 0 {
+		return 0
+	}
+	return price
+}
+
+// offsetCurrencyMatches reports whether the carbon price can be applied to
+// offer as is. Offers priced in a currency other than the requested one
+// get no estimate, since there is no conversion.
+func offsetCurrencyMatches(offer FlightOffer, params SearchParams) bool {
+	return params.Currency == "" || strings.EqualFold(offer.Currency, params.Currency)
+}
+
+// offsetCost estimates what offsetting kg of CO2 would cost, rounded to
+// cents.
+func offsetCost(kg, pricePerTonne float64) float64 {
+	return math.Round(kg/1000*pricePerTonne*100) / 100
+}
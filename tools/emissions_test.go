@@ -0,0 +1,54 @@
+package tools
+
+import "testing"
+
+func TestOffsetCostCarriesCurrency(t *testing.T) {
+	t.Setenv("CARBON_PRICE_PER_TONNE", "80")
+	oneWay, roundTrip := 500.0, 1000.0
+	offers := []FlightOffer{
+		{Currency: "EUR", CO2Kg: &oneWay},
+		{Currency: "USD", CO2Kg: &oneWay},
+		{Currency: "EUR", CO2Kg: &oneWay, CO2RoundTripKg: &roundTrip},
+	}
+
+	enrichOffers(offers, SearchParams{Currency: "EUR"})
+	if offers[0].OffsetCost == nil || *offers[0].OffsetCost != 40 || offers[0].OffsetCurrency != "EUR" {
+		t.Errorf("EUR offer: offset_cost=%v offset_currency=%q, want 40 EUR", offers[0].OffsetCost, offers[0].OffsetCurrency)
+	}
+	if offers[1].OffsetCost != nil || offers[1].OffsetCurrency != "" {
+		t.Errorf("USD offer on a EUR request: offset_cost=%v offset_currency=%q, want none", offers[1].OffsetCost, offers[1].OffsetCurrency)
+	}
+	if offers[2].OffsetCost == nil || *offers[2].OffsetCost != 80 {
+		t.Errorf("round trip of 500 kg each way: offset_cost=%v, want 80 for 1000 kg", offers[2].OffsetCost)
+	}
+}
+
+func TestRoundTripEmissionsAddBothLegs(t *testing.T) {
+	segment := func(id, flight, from, to, at, emissions string) string {
+		return `{"id":"` + id + `","carrierCode":"AA","number":"` + flight + `",` +
+			`"departure":{"iataCode":"` + from + `","at":"` + at + `T10:00:00"},"arrival":{"iataCode":"` + to + `","at":"` + at + `T11:00:00"},` +
+			`"co2Emissions":[` + emissions + `]}`
+	}
+	offer := func(id, inboundEmissions string) string {
+		return `{"id":"` + id + `","price":{"total":"200.00","currency":"USD"},"itineraries":[` +
+			`{"duration":"PT1H","segments":[` + segment("1", "1", "JFK", "BOS", "2026-01-01", `{"weight":120,"weightUnit":"KG"}`) + `]},` +
+			`{"duration":"PT1H","segments":[` + segment("2", "2", "BOS", "JFK", "2026-01-08", inboundEmissions) + `]}]}`
+	}
+
+	offers, err := parseAmadeusOffers([]byte(offersBody(
+		offer("1", `{"weight":0.13,"weightUnit":"T"}`),
+		offer("2", ""),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := offers[0].CO2Kg; got == nil || *got != 120 {
+		t.Errorf("co2_kg = %v, want the outbound 120", got)
+	}
+	if got := offers[0].CO2RoundTripKg; got == nil || *got != 250 {
+		t.Errorf("co2_round_trip_kg = %v, want 250", got)
+	}
+	if got := offers[1].CO2RoundTripKg; got != nil {
+		t.Errorf("return leg without emissions: co2_round_trip_kg = %v, want omitted", *got)
+	}
+}
@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// enrichOffers adds optional, advisory fields to offers that survived
+// filtering and ranking.
+func enrichOffers(offers []FlightOffer, params SearchParams) {
+	visaCountries, visaAirports := transitVisaRules()
+	carbonPrice := carbonPricePerTonne()
+	allianceMap := alliances()
+	ratings := layoverRatings()
+	pitches := seatPitches()
+	for i := range offers {
+		offers[i].Route = routeSummary(offers[i], params.RouteStyle)
+		offers[i].CabinMatchesRequest = cabinMatches(offers[i], params.Cabin)
+		offers[i].WithinBudget = withinBudget(offers[i], params)
+		if ratings != nil {
+			offers[i].LayoverExperience = layoverExperience(offers[i], ratings)
+		}
+		if pitches != nil {
+			offers[i].LegroomHint = legroomHint(offers[i], pitches)
+		}
+		offers[i].AllSameCarrier, offers[i].AllSameAlliance = connectionStatus(offers[i], allianceMap)
+		if kg := journeyEmissions(offers[i]); carbonPrice > 0 && kg != nil && offsetCurrencyMatches(offers[i], params) {
+			cost := offsetCost(*kg, carbonPrice)
+			offers[i].OffsetCost = &cost
+			offers[i].OffsetCurrency = offers[i].Currency
+		}
+		if params.IncludeCoords {
+			addCoordinates(&offers[i])
+		}
+		if len(visaCountries) > 0 || len(visaAirports) > 0 {
+			offers[i].TransitVisaWarning = transitVisaAirports(offers[i], visaCountries, visaAirports)
+		}
+	}
+}
+
+// withinBudget compares the fare with the soft budget. It is nil without a
+// budget, for fares in another currency, or when the price cannot be parsed.
+func withinBudget(offer FlightOffer, params SearchParams) *bool {
+	if params.Budget <= 0 {
+		return nil
+	}
+	if params.Currency != "" && offer.Currency != "" && !strings.EqualFold(params.Currency, offer.Currency) {
+		return nil
+	}
+	price, ok := parsePrice(offer.Price)
+	if !ok {
+		return nil
+	}
+	within := price <= params.Budget
+	return &within
+}
+
+// routeSummary renders the outbound routing. The default full style lists
+// every airport ("JFK→ORD→CDG"); compact keeps the endpoints and a stop
+// count ("JFK→CDG (1 stop)").
+func routeSummary(offer FlightOffer, style string) string {
+	if strings.EqualFold(strings.TrimSpace(style), "compact") {
+		switch offer.Stops {
+		case 0:
+			return offer.Origin + "→" + offer.Destination + " (nonstop)"
+		case 1:
+			return offer.Origin + "→" + offer.Destination + " (1 stop)"
+		default:
+			return fmt.Sprintf("%s→%s (%d stops)", offer.Origin, offer.Destination, offer.Stops)
+		}
+	}
+
+	airports := []string{offer.Origin}
+	airports = append(airports, connectionAirports(offer)...)
+	airports = append(airports, offer.Destination)
+	return strings.Join(airports, "→")
+}
+
+// connectionStatus reports whether every segment, on both legs, is
+// operated by the same carrier, and whether all operating carriers share
+// an alliance. Single carrier itineraries count as same-alliance even for
+// unaligned carriers.
+func connectionStatus(offer FlightOffer, allianceMap map[string][]string) (sameCarrier, sameAlliance bool) {
+	carriers := map[string]bool{}
+	for _, segments := range [][]Segment{offer.Segments, offer.ReturnSegments} {
+		for _, segment := range segments {
+			carrier := segment.OperatingCarrier
+			if carrier == "" {
+				carrier = segment.Carrier
+			}
+			carriers[carrier] = true
+		}
+	}
+	if len(carriers) == 0 {
+		return false, false
+	}
+	if len(carriers) == 1 {
+		return true, true
+	}
+
+	for _, members := range allianceMap {
+		set := carrierSet(members)
+		all := true
+		for carrier := range carriers {
+			if !set[carrier] {
+				all = false
+				break
+			}
+		}
+		if all {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// transitVisaRules reads FLIGHT_TRANSIT_VISA_COUNTRIES and
+// FLIGHT_TRANSIT_VISA_AIRPORTS (comma-separated codes). Both are empty by
+// default, which disables the check: transit rules depend on the
+// traveler's passport, so there is no safe built-in list.
+func transitVisaRules() (countries, airportCodes map[string]bool) {
+	return codeSet(strings.Split(os.Getenv("FLIGHT_TRANSIT_VISA_COUNTRIES"), ",")),
+		codeSet(strings.Split(os.Getenv("FLIGHT_TRANSIT_VISA_AIRPORTS"), ","))
+}
+
+func codeSet(codes []string) map[string]bool {
+	set := map[string]bool{}
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
+
+// transitVisaAirports flags connections on either leg that may need a
+// transit visa. Connections in the origin or destination country are
+// skipped since the traveler needs entry documents there anyway. This is
+// advisory only.
+func transitVisaAirports(offer FlightOffer, countries, airportCodes map[string]bool) []string {
+	origin, _ := lookupAirport(offer.Origin)
+	destination, _ := lookupAirport(offer.Destination)
+
+	var flagged []string
+	for _, airport := range roundTripConnections(offer) {
+		if airportCodes[airport] {
+			flagged = append(flagged, airport)
+			continue
+		}
+		info, ok := lookupAirport(airport)
+		if !ok || !countries[info.Country] || info.Country == origin.Country || info.Country == destination.Country {
+			continue
+		}
+		flagged = append(flagged, airport)
+	}
+	return flagged
+}
+
+// addCoordinates fills coordinates from the built-in airport table.
+// Airports missing from the table are left out.
+func addCoordinates(offer *FlightOffer) {
+	if info, ok := lookupAirport(offer.Origin); ok {
+		offer.OriginCoords = &Coordinates{Lat: info.Lat, Lon: info.Lon}
+	}
+	if info, ok := lookupAirport(offer.Destination); ok {
+		offer.DestinationCoords = &Coordinates{Lat: info.Lat, Lon: info.Lon}
+	}
+	for _, airport := range connectionAirports(*offer) {
+		if info, ok := lookupAirport(airport); ok {
+			if offer.ConnectionCoords == nil {
+				offer.ConnectionCoords = map[string]Coordinates{}
+			}
+			offer.ConnectionCoords[airport] = Coordinates{Lat: info.Lat, Lon: info.Lon}
+		}
+	}
+}
+
+// connectionAirports lists the airports where the outbound journey changes
+// planes, in travel order.
+func connectionAirports(offer FlightOffer) []string {
+	return legConnections(offer.Segments)
+}
+
+// roundTripConnections lists the connection airports of both legs, outbound
+// first.
+func roundTripConnections(offer FlightOffer) []string {
+	return append(legConnections(offer.Segments), legConnections(offer.ReturnSegments)...)
+}
+
+func legConnections(segments []Segment) []string {
+	if len(segments) < 2 {
+		return nil
+	}
+	connections := make([]string, 0, len(segments)-1)
+	for _, segment := range segments[:len(segments)-1] {
+		connections = append(connections, segment.To)
+	}
+	return connections
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConnectionStatusIncludesReturnLeg(t *testing.T) {
+	offer := FlightOffer{
+		Segments:       []Segment{{Carrier: "BA"}, {Carrier: "BA"}},
+		ReturnSegments: []Segment{{Carrier: "SU"}},
+	}
+	sameCarrier, sameAlliance := connectionStatus(offer, alliances())
+	if sameCarrier || sameAlliance {
+		t.Errorf("BA out, SU back: all_same_carrier=%v all_same_alliance=%v, want false, false", sameCarrier, sameAlliance)
+	}
+
+	offer.ReturnSegments = []Segment{{Carrier: "AA", OperatingCarrier: "AA"}}
+	sameCarrier, sameAlliance = connectionStatus(offer, alliances())
+	if sameCarrier || !sameAlliance {
+		t.Errorf("BA out, AA back: all_same_carrier=%v all_same_alliance=%v, want false, true", sameCarrier, sameAlliance)
+	}
+}
+
+func TestTransitVisaWarningIsOptIn(t *testing.T) {
+	offers := []FlightOffer{{
+		Origin:      "JFK",
+		Destination: "DXB",
+		Segments:    []Segment{{From: "JFK", To: "LHR"}, {From: "LHR", To: "DXB"}},
+	}}
+
+	enrichOffers(offers, SearchParams{})
+	if got := offers[0].TransitVisaWarning; len(got) != 0 {
+		t.Errorf("unconfigured: transit_visa_warning = %v, want none", got)
+	}
+
+	t.Setenv("FLIGHT_TRANSIT_VISA_COUNTRIES", "gb")
+	enrichOffers(offers, SearchParams{})
+	if got := offers[0].TransitVisaWarning; len(got) != 1 || got[0] != "LHR" {
+		t.Errorf("GB configured: transit_visa_warning = %v, want [LHR]", got)
+	}
+
+	offers[0].ReturnSegments = []Segment{{From: "DXB", To: "SVO"}, {From: "SVO", To: "JFK"}}
+	t.Setenv("FLIGHT_TRANSIT_VISA_COUNTRIES", "RU")
+	enrichOffers(offers, SearchParams{})
+	if got := offers[0].TransitVisaWarning; len(got) != 1 || got[0] != "SVO" {
+		t.Errorf("return via SVO with RU configured: transit_visa_warning = %v, want [SVO]", got)
+	}
+}
+
+func TestCoordinatesForKnownAirportsOnly(t *testing.T) {
+	offers := []FlightOffer{{
+		Origin:      "JFK",
+		Destination: "XQZ",
+		Segments:    []Segment{{From: "JFK", To: "LHR"}, {From: "LHR", To: "QQQ"}, {From: "QQQ", To: "XQZ"}},
+	}}
+
+	enrichOffers(offers, SearchParams{IncludeCoords: true})
+	offer := offers[0]
+	if offer.OriginCoords == nil || *offer.OriginCoords != (Coordinates{Lat: 40.6413, Lon: -73.7781}) {
+		t.Errorf("origin_coords = %v, want JFK", offer.OriginCoords)
+	}
+	if offer.DestinationCoords != nil {
+		t.Errorf("destination_coords = %v, want omitted for an unknown airport", offer.DestinationCoords)
+	}
+	if len(offer.ConnectionCoords) != 1 || offer.ConnectionCoords["LHR"] != (Coordinates{Lat: 51.4700, Lon: -0.4543}) {
+		t.Errorf("connection_coords = %v, want LHR only", offer.ConnectionCoords)
+	}
+
+	offers[0] = FlightOffer{Origin: "JFK", Destination: "LHR"}
+	enrichOffers(offers, SearchParams{})
+	if offers[0].OriginCoords != nil {
+		t.Error("coordinates added without include_coords")
+	}
+}
+
+func TestRouteSummaryStyles(t *testing.T) {
+	offer := FlightOffer{
+		Origin:      "JFK",
+		Destination: "SIN",
+		Stops:       2,
+		Segments:    []Segment{{From: "JFK", To: "LHR"}, {From: "LHR", To: "DXB"}, {From: "DXB", To: "SIN"}},
+	}
+	for style, want := range map[string]string{
+		"":        "JFK→LHR→DXB→SIN",
+		"full":    "JFK→LHR→DXB→SIN",
+		"Compact": "JFK→SIN (2 stops)",
+	} {
+		if got := routeSummary(offer, style); got != want {
+			t.Errorf("route_style %q: route = %q, want %q", style, got, want)
+		}
+	}
+}
+
+func TestOverBudgetOffersAreKeptAndFlagged(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	base := mockBasePrice("SFO", "JFK", "2026-03-15")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "SFO",
+		Destination: "JFK",
+		DepartDate:  "2026-03-15",
+		Budget:      base + 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) != 3 {
+		t.Fatalf("got %d offers, want all 3 kept", len(outcome.Offers))
+	}
+	for _, offer := range outcome.Offers {
+		price, _ := parsePrice(offer.Price)
+		if offer.WithinBudget == nil || *offer.WithinBudget != (price <= base+10) {
+			t.Errorf("offer at %s: within_budget = %v, budget %.2f", offer.Price, offer.WithinBudget, base+10)
+		}
+	}
+
+	if got := withinBudget(FlightOffer{Price: "10.00", Currency: "EUR"}, SearchParams{Budget: 500, Currency: "USD"}); got != nil {
+		t.Errorf("fare in another currency: within_budget = %v, want omitted", *got)
+	}
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	amadeusTestURL       = "https://test.api.amadeus.com"
+	amadeusProductionURL = "https://api.amadeus.com"
+)
+
+// resolveBaseURL picks the Amadeus host. An explicit AMADEUS_BASE_URL
+// always wins, then AMADEUS_ENV (test or production), then the test
+// environment. Setting both to disagreeing values logs a warning.
+func resolveBaseURL() string {
+	explicit := strings.TrimRight(strings.TrimSpace(os.Getenv("AMADEUS_BASE_URL")), "/")
+	env := strings.ToLower(strings.TrimSpace(os.Getenv("AMADEUS_ENV")))
+
+	envURL := ""
+	switch env {
+	case "":
+	case "test", "sandbox":
+		envURL = amadeusTestURL
+	case "production", "prod":
+		envURL = amadeusProductionURL
+	default:
+		logf("unknown AMADEUS_ENV %q; expected test or production", env)
+	}
+
+	if explicit != "" {
+		if envURL != "" && explicit != envURL {
+			logf("AMADEUS_BASE_URL %s overrides AMADEUS_ENV=%s (%s)", explicit, env, envURL)
+		}
+		return explicit
+	}
+	if envURL != "" {
+		return envURL
+	}
+	return amadeusTestURL
+}
+
+func isSandboxURL(baseURL string) bool {
+	return strings.Contains(baseURL, "test.api.amadeus.com")
+}
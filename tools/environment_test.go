@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL, env string
+		want         string
+		warns        bool
+	}{
+		{"", "", amadeusTestURL, false},
+		{"", "test", amadeusTestURL, false},
+		{"", "Production", amadeusProductionURL, false},
+		{"", "staging", amadeusTestURL, true},
+		{"https://proxy.example.com/", "", "https://proxy.example.com", false},
+		{"https://api.amadeus.com", "prod", amadeusProductionURL, false},
+		{"https://proxy.example.com", "production", "https://proxy.example.com", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("AMADEUS_BASE_URL", tt.baseURL)
+		t.Setenv("AMADEUS_ENV", tt.env)
+		lines := captureLog(t)
+
+		if got := resolveBaseURL(); got != tt.want {
+			t.Errorf("AMADEUS_BASE_URL=%q AMADEUS_ENV=%q: got %s, want %s", tt.baseURL, tt.env, got, tt.want)
+		}
+		if warned := len(*lines) > 0; warned != tt.warns {
+			t.Errorf("AMADEUS_BASE_URL=%q AMADEUS_ENV=%q: log = %q, want warning %v", tt.baseURL, tt.env, *lines, tt.warns)
+		}
+	}
+}
+
+func TestResolveBaseURLConflictWarning(t *testing.T) {
+	t.Setenv("AMADEUS_BASE_URL", "https://proxy.example.com")
+	t.Setenv("AMADEUS_ENV", "production")
+	lines := captureLog(t)
+
+	resolveBaseURL()
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], "overrides AMADEUS_ENV=production") {
+		t.Errorf("log = %q, want one override warning", *lines)
+	}
+}
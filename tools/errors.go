@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ArgumentError reports a tool argument that cannot produce a meaningful
+// search. It is returned before any Amadeus request is made.
+type ArgumentError struct {
+	Arg string
+	Msg string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Arg, e.Msg)
+}
+
+// ErrMissingCredentials is returned when live Amadeus access is needed but
+// AMADEUS_CLIENT_ID or AMADEUS_CLIENT_SECRET is unset.
+var ErrMissingCredentials = errors.New("missing AMADEUS_CLIENT_ID or AMADEUS_CLIENT_SECRET")
+
+// UpstreamError reports a non-2xx response from Amadeus.
+type UpstreamError struct {
+	Request    string
+	StatusCode int
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("amadeus %s request failed: %d %s", e.Request, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Error codes reported as error_code in the tool payload.
+const (
+	ErrorCodeMissingCredentials = "missing_credentials"
+	ErrorCodeInvalidArgument    = "invalid_argument"
+	ErrorCodeRateLimited        = "rate_limited"
+	ErrorCodeUpstream           = "upstream_error"
+	ErrorCodeNoResults          = "no_results"
+)
+
+// ErrorCode maps err to one of the ErrorCode constants. Errors without a
+// more specific type (network failures, unreadable responses) are treated
+// as upstream errors.
+func ErrorCode(err error) string {
+	var argErr *ArgumentError
+	var upstreamErr *UpstreamError
+	switch {
+	case errors.As(err, &argErr):
+		return ErrorCodeInvalidArgument
+	case errors.Is(err, ErrMissingCredentials):
+		return ErrorCodeMissingCredentials
+	case errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	default:
+		return ErrorCodeUpstream
+	}
+}
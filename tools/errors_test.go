@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestErrorCodeForEachFailure(t *testing.T) {
+	search := map[string]interface{}{"origin": "JFK", "destination": "BOS", "depart_date": "2026-01-01"}
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		args  map[string]interface{}
+		want  string
+	}{
+		{"missing credentials", func(t *testing.T) {
+			t.Setenv("AMADEUS_MOCK", "")
+			t.Setenv("AMADEUS_CLIENT_ID", "")
+		}, search, ErrorCodeMissingCredentials},
+		{"invalid argument", func(t *testing.T) {
+			t.Setenv("AMADEUS_MOCK", "true")
+		}, map[string]interface{}{"origin": "JFK", "destination": "JFK", "depart_date": "2026-01-01"}, ErrorCodeInvalidArgument},
+		{"rate limited", func(t *testing.T) {
+			fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+		}, search, ErrorCodeRateLimited},
+		{"upstream error", func(t *testing.T) {
+			fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+		}, search, ErrorCodeUpstream},
+		{"no results", func(t *testing.T) {
+			t.Setenv("AMADEUS_MOCK", "true")
+		}, map[string]interface{}{"origin": "JFK", "destination": "BOS", "depart_date": "2026-01-01", "max_price": 1.0}, ErrorCodeNoResults},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t)
+			payload, err := executeTool(t, tt.args)
+			if got := payload["error_code"]; got != tt.want {
+				t.Errorf("error_code = %v, want %s", got, tt.want)
+			}
+			if tt.want == ErrorCodeNoResults && err != nil {
+				t.Errorf("no results: err = %v, want a successful call", err)
+			}
+		})
+	}
+
+	if got := ErrorCode(errors.New("dial tcp: connection refused")); got != ErrorCodeUpstream {
+		t.Errorf("network error: ErrorCode = %s, want %s", got, ErrorCodeUpstream)
+	}
+}
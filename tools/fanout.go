@@ -0,0 +1,398 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	defaultConcurrency = 4
+	maxFlexDays        = 7
+)
+
+type searchRequest struct {
+	Origin      string
+	Destination string
+	DepartDate  string
+	ReturnDate  string
+}
+
+type route struct {
+	origin      string
+	destination string
+}
+
+// planRequests expands flex_days and include_nearby into one request per
+// route and date pair. The requested route comes first, then alternates,
+// and within each route dates are ordered by distance from the requested
+// ones, so that trimming always drops the least relevant requests first.
+func planRequests(params SearchParams) []searchRequest {
+	dates := planDates(params)
+	requests := make([]searchRequest, 0, len(dates))
+	for _, r := range planRoutes(params) {
+		for _, d := range dates {
+			d.Origin, d.Destination = r.origin, r.destination
+			requests = append(requests, d)
+		}
+	}
+	return requests
+}
+
+func planRoutes(params SearchParams) []route {
+	routes := []route{{origin: params.Origin, destination: params.Destination}}
+	if !params.IncludeNearby {
+		return routes
+	}
+
+	origins := append([]string{params.Origin}, nearbyAirports(params.Origin)...)
+	destinations := append([]string{params.Destination}, nearbyAirports(params.Destination)...)
+	for i, origin := range origins {
+		for j, destination := range destinations {
+			if i == 0 && j == 0 {
+				continue
+			}
+			if metroCode(origin) == metroCode(destination) {
+				continue
+			}
+			routes = append(routes, route{origin: origin, destination: destination})
+		}
+	}
+	return routes
+}
+
+func planDates(params SearchParams) []searchRequest {
+	exact := searchRequest{DepartDate: params.DepartDate, ReturnDate: params.ReturnDate}
+
+	flex := params.FlexDays
+	if flex > maxFlexDays {
+		flex = maxFlexDays
+	}
+	depart, err := time.Parse(dateLayout, params.DepartDate)
+	if flex <= 0 || err != nil {
+		return []searchRequest{exact}
+	}
+	var ret time.Time
+	if params.ReturnDate != "" {
+		ret, err = time.Parse(dateLayout, params.ReturnDate)
+		if err != nil {
+			return []searchRequest{exact}
+		}
+	}
+
+	dates := []searchRequest{exact}
+	for offset := 1; offset <= flex; offset++ {
+		for _, shift := range []int{-offset, offset} {
+			req := searchRequest{DepartDate: depart.AddDate(0, 0, shift).Format(dateLayout)}
+			if !ret.IsZero() {
+				req.ReturnDate = ret.AddDate(0, 0, shift).Format(dateLayout)
+			}
+			dates = append(dates, req)
+		}
+	}
+	return dates
+}
+
+// requestBudget enforces FLIGHT_MAX_REQUESTS_PER_CALL across every fan-out
+// of one tool call: the main search, relax_if_empty, each min_results step
+// and the suggest_nearby probe all draw on the same allowance.
+type requestBudget struct {
+	limit   int
+	used    int
+	planned int
+	dropped []searchRequest
+	skipped []string
+}
+
+func newRequestBudget() *requestBudget {
+	return &requestBudget{limit: maxRequestsPerCall()}
+}
+
+// left is the number of requests still allowed, or -1 without a cap.
+func (b *requestBudget) left() int {
+	if b.limit <= 0 {
+		return -1
+	}
+	if b.used >= b.limit {
+		return 0
+	}
+	return b.limit - b.used
+}
+
+func (b *requestBudget) exhausted() bool {
+	return b.left() == 0
+}
+
+// take keeps as many requests as the budget allows, in order, and records
+// the rest as dropped. Kept requests are not charged until spend.
+func (b *requestBudget) take(requests []searchRequest) []searchRequest {
+	b.planned += len(requests)
+	left := b.left()
+	if left < 0 || len(requests) <= left {
+		return requests
+	}
+	b.dropped = append(b.dropped, requests[left:]...)
+	return requests[:left]
+}
+
+func (b *requestBudget) spend(n int) {
+	b.used += n
+}
+
+// skip records a follow-up search that did not run for lack of budget.
+func (b *requestBudget) skip(name string) {
+	b.skipped = append(b.skipped, name)
+}
+
+// trimmed reports what the cap cut from the call, or nil when nothing was.
+// Dropped requests are listed as route and date pairs; a depart date or
+// route is only reported as dropped when no searched request covers it.
+func (b *requestBudget) trimmed(searched []searchRequest) map[string]interface{} {
+	if len(b.dropped) == 0 && len(b.skipped) == 0 {
+		return nil
+	}
+
+	searchedDates := map[string]bool{}
+	searchedRoutes := map[string]bool{}
+	for _, req := range searched {
+		searchedDates[req.DepartDate] = true
+		searchedRoutes[req.Origin+"-"+req.Destination] = true
+	}
+	droppedRequests := map[string]bool{}
+	droppedDates := map[string]bool{}
+	droppedRoutes := map[string]bool{}
+	for _, req := range b.dropped {
+		name := req.Origin + "-" + req.Destination
+		droppedRequests[requestLabel(req)] = true
+		if !searchedDates[req.DepartDate] {
+			droppedDates[req.DepartDate] = true
+		}
+		if !searchedRoutes[name] {
+			droppedRoutes[name] = true
+		}
+	}
+
+	trimmed := map[string]interface{}{
+		"reason":           "FLIGHT_MAX_REQUESTS_PER_CALL",
+		"planned_requests": b.planned,
+		"max_requests":     b.limit,
+	}
+	if len(droppedRequests) > 0 {
+		trimmed["dropped_requests"] = sortedKeys(droppedRequests)
+	}
+	if len(droppedDates) > 0 {
+		trimmed["dropped_depart_dates"] = sortedKeys(droppedDates)
+	}
+	if len(droppedRoutes) > 0 {
+		trimmed["dropped_routes"] = sortedKeys(droppedRoutes)
+	}
+	if len(b.skipped) > 0 {
+		trimmed["skipped"] = b.skipped
+	}
+	return trimmed
+}
+
+// ProgressFunc receives the number of completed Amadeus requests out of
+// total for one fan-out round. Calls are serialized and done only grows.
+type ProgressFunc func(done, total int)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose searches report fan-out progress to
+// fn. A search may run more than one round (relax_if_empty, min_results,
+// suggest_nearby), each starting again from zero.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progressFrom(ctx context.Context, total int) func() {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	if fn == nil {
+		return func() {}
+	}
+	var mu sync.Mutex
+	done := 0
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		fn(done, total)
+	}
+}
+
+// runRequests fans requests out, holding a slot of sem for each request in
+// flight. Every worker gives up its slot wait on cancellation and the HTTP
+// requests share the same context, so cancelling ctx (or the first
+// failure) stops all in-flight work instead of letting it run to its
+// timeout.
+func runRequests(ctx context.Context, fetcher offerFetcher, sem chan struct{}, params SearchParams, requests []searchRequest) ([]FlightOffer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	completed := progressFrom(ctx, len(requests))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]FlightOffer, len(requests))
+	var firstErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+
+	for i, req := range requests {
+		wg.Add(1)
+		go func(i int, req searchRequest) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-runCtx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if runCtx.Err() != nil {
+				return
+			}
+			offers, err := fetcher.fetchOffers(runCtx, params, req)
+			completed()
+			if err != nil {
+				fail(err)
+				return
+			}
+			tagRequest(offers, req)
+			results[i] = offers
+		}(i, req)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	merged := make([]FlightOffer, 0)
+	for _, offers := range results {
+		merged = append(merged, offers...)
+	}
+	return merged, nil
+}
+
+// tagRequest records the request that returned each offer. Amadeus offer
+// ids only count up within one response ("1", "2", ...), so once a fan-out
+// merges several responses an offer is identified by request and
+// amadeus_offer_id together.
+func tagRequest(offers []FlightOffer, req searchRequest) {
+	label := requestLabel(req)
+	for i := range offers {
+		offers[i].Request = label
+	}
+}
+
+// requestLabel renders a request as "JFK-LHR 2026-11-10", with
+// "/<return date>" appended for round trips.
+func requestLabel(req searchRequest) string {
+	label := req.Origin + "-" + req.Destination + " " + req.DepartDate
+	if req.ReturnDate != "" {
+		label += "/" + req.ReturnDate
+	}
+	return label
+}
+
+func mergeWindow(window, requests []searchRequest) []searchRequest {
+	for _, req := range requests {
+		seen := false
+		for _, existing := range window {
+			if existing == req {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			window = append(window, req)
+		}
+	}
+	return window
+}
+
+// searchWindow reports the requested dates alongside every date pair that
+// was actually searched, which differs from the request under flex_days
+// and request trimming.
+func searchWindow(params SearchParams, window []searchRequest) map[string]interface{} {
+	pairs := make([]map[string]string, 0, len(window))
+	departSet := map[string]bool{}
+	returnSet := map[string]bool{}
+	for _, req := range window {
+		pair := map[string]string{
+			"origin":      req.Origin,
+			"destination": req.Destination,
+			"depart_date": req.DepartDate,
+		}
+		departSet[req.DepartDate] = true
+		if req.ReturnDate != "" {
+			pair["return_date"] = req.ReturnDate
+			returnSet[req.ReturnDate] = true
+		}
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i]["depart_date"] < pairs[j]["depart_date"] })
+
+	return map[string]interface{}{
+		"requested": map[string]interface{}{
+			"depart_date": params.DepartDate,
+			"return_date": params.ReturnDate,
+			"flex_days":   params.FlexDays,
+		},
+		"depart_dates": sortedKeys(departSet),
+		"return_dates": sortedKeys(returnSet),
+		"pairs":        pairs,
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func maxRequestsPerCall() int {
+	return envInt("FLIGHT_MAX_REQUESTS_PER_CALL", 0)
+}
+
+func newLimiter() chan struct{} {
+	return make(chan struct{}, maxConcurrency())
+}
+
+func maxConcurrency() int {
+	if n := envInt("FLIGHT_MAX_CONCURRENCY", defaultConcurrency); n > 0 {
+		return n
+	}
+	return defaultConcurrency
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
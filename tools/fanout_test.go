@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetcherFunc adapts a function to offerFetcher.
+type fetcherFunc func(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error)
+
+func (f fetcherFunc) fetchOffers(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+	return f(ctx, params, req)
+}
+
+func TestRequestCapCoversWholeCall(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_MAX_REQUESTS_PER_CALL", "3")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:       "JFK",
+		Destination:  "LHR",
+		DepartDate:   "2026-11-10",
+		FlexDays:     2,
+		MaxPrice:     1,
+		RelaxIfEmpty: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requests := outcome.Meta["requests"].(int); requests > 3 {
+		t.Fatalf("requests = %d, want at most 3", requests)
+	}
+	trimmed, ok := outcome.Meta["trimmed"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("meta.trimmed missing: %v", outcome.Meta)
+	}
+	if planned := trimmed["planned_requests"].(int); planned != 5 {
+		t.Errorf("planned_requests = %d, want 5", planned)
+	}
+	if dropped, _ := trimmed["dropped_requests"].([]string); len(dropped) != 2 {
+		t.Errorf("dropped_requests = %v, want 2 pairs", dropped)
+	}
+	for _, date := range trimmed["dropped_depart_dates"].([]string) {
+		if date == "2026-11-10" {
+			t.Errorf("searched date %s reported as dropped", date)
+		}
+	}
+	if skipped, _ := trimmed["skipped"].([]string); len(skipped) != 1 || skipped[0] != "relax_if_empty" {
+		t.Errorf("skipped = %v, want [relax_if_empty]", skipped)
+	}
+}
+
+func TestRequestBudgetTrimmedPairs(t *testing.T) {
+	budget := &requestBudget{limit: 2}
+	requests := []searchRequest{
+		{Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10"},
+		{Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-09"},
+		{Origin: "EWR", Destination: "LHR", DepartDate: "2026-11-10"},
+	}
+	kept := budget.take(requests)
+	budget.spend(len(kept))
+
+	trimmed := budget.trimmed(kept)
+	if got := trimmed["dropped_requests"].([]string); len(got) != 1 || got[0] != "EWR-LHR 2026-11-10" {
+		t.Errorf("dropped_requests = %v", got)
+	}
+	if _, ok := trimmed["dropped_depart_dates"]; ok {
+		t.Errorf("dropped_depart_dates = %v, want none: every date was searched", trimmed["dropped_depart_dates"])
+	}
+	if got := trimmed["dropped_routes"].([]string); len(got) != 1 || got[0] != "EWR-LHR" {
+		t.Errorf("dropped_routes = %v", got)
+	}
+	if !budget.exhausted() {
+		t.Error("budget should be exhausted")
+	}
+}
+
+func TestProgressCountsUpToTotal(t *testing.T) {
+	var seen []int
+	total := 0
+	ctx := WithProgress(context.Background(), func(done, n int) {
+		seen = append(seen, done)
+		total = n
+	})
+	fetcher := fetcherFunc(func(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+		return nil, nil
+	})
+
+	if _, err := runRequests(ctx, fetcher, newLimiter(), SearchParams{}, make([]searchRequest, 9)); err != nil {
+		t.Fatal(err)
+	}
+	if total != 9 || len(seen) != 9 {
+		t.Fatalf("progress calls = %v with total %d, want 9 calls of 9", seen, total)
+	}
+	for i, done := range seen {
+		if done != i+1 {
+			t.Fatalf("progress = %v, want 1..9 in order", seen)
+		}
+	}
+}
+
+func TestSearchWindowReportsEveryPair(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "JFK",
+		Destination: "LHR",
+		DepartDate:  "2026-11-10",
+		ReturnDate:  "2026-11-17",
+		FlexDays:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	window := outcome.Meta["search_window"].(map[string]interface{})
+	requested := window["requested"].(map[string]interface{})
+	if requested["depart_date"] != "2026-11-10" || requested["flex_days"] != 1 {
+		t.Errorf("requested = %v", requested)
+	}
+	want := [][2]string{{"2026-11-09", "2026-11-16"}, {"2026-11-10", "2026-11-17"}, {"2026-11-11", "2026-11-18"}}
+	pairs := window["pairs"].([]map[string]string)
+	if len(pairs) != len(want) {
+		t.Fatalf("pairs = %v, want %d", pairs, len(want))
+	}
+	for i, pair := range pairs {
+		if pair["origin"] != "JFK" || pair["destination"] != "LHR" || pair["depart_date"] != want[i][0] || pair["return_date"] != want[i][1] {
+			t.Errorf("pair %d = %v, want JFK-LHR %s/%s", i, pair, want[i][0], want[i][1])
+		}
+	}
+	if dates := window["depart_dates"].([]string); len(dates) != 3 || dates[0] != "2026-11-09" || dates[2] != "2026-11-11" {
+		t.Errorf("depart_dates = %v", dates)
+	}
+}
+
+func TestCancelMidFanOutReturnsPromptly(t *testing.T) {
+	t.Setenv("FLIGHT_MAX_CONCURRENCY", "2")
+	var started int32
+	fetcher := fetcherFunc(func(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+		atomic.AddInt32(&started, 1)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := runRequests(ctx, fetcher, newLimiter(), SearchParams{}, make([]searchRequest, 10))
+		done <- err
+	}()
+	for atomic.LoadInt32(&started) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runRequests still running a second after cancel")
+	}
+	if n := atomic.LoadInt32(&started); n != 2 {
+		t.Errorf("%d fetches started, want only the 2 holding the limiter", n)
+	}
+}
@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRedeyeWindow  = "00:00-05:00"
+	defaultBusinessHours = "06:00-21:00"
+	defaultPriceRounding = "ceil"
+
+	defaultMinConnectionMinutes = 60
+)
+
+type offerFilter struct {
+	name string
+	keep func(FlightOffer) bool
+}
+
+// activeFilters lists the post-search filters enabled by params, in the
+// order they run.
+func activeFilters(params SearchParams) []offerFilter {
+	var filters []offerFilter
+
+	if params.MaxPrice > 0 {
+		round := priceRounder(params.PriceRounding)
+		filters = append(filters, offerFilter{name: "max_price", keep: func(offer FlightOffer) bool {
+			if params.Currency != "" && offer.Currency != "" && !strings.EqualFold(params.Currency, offer.Currency) {
+				return true
+			}
+			price, ok := parsePrice(offer.Price)
+			return !ok || round(price) <= params.MaxPrice
+		}})
+	}
+
+	if len(params.FlightNumbers) > 0 {
+		allowed := map[string]bool{}
+		for _, number := range params.FlightNumbers {
+			allowed[normalizeFlightNumber(number)] = true
+		}
+		filters = append(filters, offerFilter{name: "flight_numbers", keep: func(offer FlightOffer) bool {
+			for _, segments := range [][]Segment{offer.Segments, offer.ReturnSegments} {
+				for _, segment := range segments {
+					if allowed[normalizeFlightNumber(segment.FlightNumber)] {
+						return true
+					}
+				}
+			}
+			return allowed[normalizeFlightNumber(offer.FlightNumber)]
+		}})
+	}
+
+	if params.ExcludeRedeye {
+		start, end := redeyeWindow()
+		filters = append(filters, offerFilter{name: "redeye", keep: func(offer FlightOffer) bool {
+			return !isRedeye(offer, start, end)
+		}})
+	}
+
+	if params.BusinessHoursOnly {
+		start, end := businessHours()
+		filters = append(filters, offerFilter{name: "business_hours", keep: func(offer FlightOffer) bool {
+			return departsWithin(offer, start, end)
+		}})
+	}
+
+	if params.ExcludeShortLayovers {
+		global, byAirport := minConnectionTimes()
+		filters = append(filters, offerFilter{name: "short_layovers", keep: func(offer FlightOffer) bool {
+			return meetsConnectionTimes(offer, global, byAirport)
+		}})
+	}
+
+	if params.NoOvernightStay {
+		filters = append(filters, offerFilter{name: "no_overnight_stay", keep: func(offer FlightOffer) bool {
+			return !offer.RequiresOvernightStay
+		}})
+	}
+
+	if params.BagsIncluded {
+		filters = append(filters, offerFilter{name: "bags_included", keep: func(offer FlightOffer) bool {
+			for _, segment := range offer.Segments {
+				if segment.CheckedBags == 0 {
+					return false
+				}
+			}
+			return len(offer.Segments) > 0
+		}})
+	}
+
+	if len(params.AllowedConnectionCountries) > 0 {
+		allowed := codeSet(params.AllowedConnectionCountries)
+		filters = append(filters, offerFilter{name: "connection_countries", keep: func(offer FlightOffer) bool {
+			return connectsWithin(offer, allowed, params.StrictConnectionCountries)
+		}})
+	}
+
+	if params.BookableWithinDays > 0 {
+		keepMissing := !strings.EqualFold(os.Getenv("FLIGHT_MISSING_TICKETING_DATE"), "drop")
+		today := now()
+		filters = append(filters, offerFilter{name: "bookable_within_days", keep: func(offer FlightOffer) bool {
+			return bookableFor(offer, today, params.BookableWithinDays, keepMissing)
+		}})
+	}
+
+	return filters
+}
+
+// connectsWithin reports whether every connection, on both legs, is in an
+// allowed country. Connections in the origin or destination country are always
+// allowed. Airports missing from the reference table pass unless strict is
+// set.
+func connectsWithin(offer FlightOffer, allowed map[string]bool, strict bool) bool {
+	origin, _ := lookupAirport(offer.Origin)
+	destination, _ := lookupAirport(offer.Destination)
+	for _, airport := range roundTripConnections(offer) {
+		info, ok := lookupAirport(airport)
+		if !ok {
+			if strict {
+				return false
+			}
+			continue
+		}
+		if info.Country == origin.Country || info.Country == destination.Country {
+			continue
+		}
+		if !allowed[info.Country] {
+			return false
+		}
+	}
+	return true
+}
+
+// minConnectionTimes reads FLIGHT_MIN_CONNECTION_MINUTES, the global
+// minimum connection time, and FLIGHT_MIN_CONNECTION_AIRPORTS, a JSON
+// object of airport code → minutes that overrides it per airport.
+func minConnectionTimes() (int, map[string]int) {
+	global := envInt("FLIGHT_MIN_CONNECTION_MINUTES", defaultMinConnectionMinutes)
+	if global < 0 {
+		global = defaultMinConnectionMinutes
+	}
+
+	value := os.Getenv("FLIGHT_MIN_CONNECTION_AIRPORTS")
+	if value == "" {
+		return global, nil
+	}
+	var minutes map[string]int
+	if err := json.Unmarshal([]byte(value), &minutes); err != nil {
+		logf("ignoring malformed FLIGHT_MIN_CONNECTION_AIRPORTS: %v", err)
+		return global, nil
+	}
+	byAirport := make(map[string]int, len(minutes))
+	for code, mct := range minutes {
+		byAirport[strings.ToUpper(strings.TrimSpace(code))] = mct
+	}
+	return global, byAirport
+}
+
+// meetsConnectionTimes reports whether every connection, on both legs,
+// allows at least the airport's minimum connection time.
+func meetsConnectionTimes(offer FlightOffer, global int, byAirport map[string]int) bool {
+	layovers := append(computeLayovers(offer.Segments), computeLayovers(offer.ReturnSegments)...)
+	for _, layover := range layovers {
+		mct, ok := byAirport[layover.Airport]
+		if !ok {
+			mct = global
+		}
+		if layover.Minutes < mct {
+			return false
+		}
+	}
+	return true
+}
+
+// now is replaceable so date-relative filters can be exercised
+// deterministically.
+var now = time.Now
+
+// bookableFor reports whether the fare can still be ticketed at least days
+// calendar days from today. Offers without a parseable lastTicketingDate
+// follow FLIGHT_MISSING_TICKETING_DATE (keep by default, or drop).
+func bookableFor(offer FlightOffer, today time.Time, days int, keepMissing bool) bool {
+	deadline, err := time.Parse(dateLayout, dateFromISO(offer.LastTicketingDate))
+	if err != nil {
+		return keepMissing
+	}
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	return !deadline.Before(start.AddDate(0, 0, days))
+}
+
+// priceRounder returns the rounding applied to fares before comparing them
+// with max_price, in whole currency units like the Amadeus maxPrice
+// parameter. The mode comes from the price_rounding argument, then
+// FLIGHT_PRICE_ROUNDING, then defaults to ceil so a fare is never let
+// through above the cap. floor and nearest are more lenient at the
+// boundary.
+func priceRounder(mode string) func(float64) float64 {
+	if mode == "" {
+		mode = os.Getenv("FLIGHT_PRICE_ROUNDING")
+	}
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "floor":
+		return math.Floor
+	case "nearest":
+		return math.Round
+	case "ceil":
+		return math.Ceil
+	default:
+		if mode != "" {
+			logf("unknown price rounding %q; using %s", mode, defaultPriceRounding)
+		}
+		return math.Ceil
+	}
+}
+
+// redeyeWindow reads FLIGHT_REDEYE_WINDOW ("HH:MM-HH:MM", local time),
+// falling back to 00:00-05:00.
+func redeyeWindow() (int, int) {
+	if start, end, ok := parseClockRange(os.Getenv("FLIGHT_REDEYE_WINDOW")); ok {
+		return start, end
+	}
+	start, end, _ := parseClockRange(defaultRedeyeWindow)
+	return start, end
+}
+
+// businessHours reads FLIGHT_BUSINESS_HOURS ("HH:MM-HH:MM", local time),
+// falling back to 06:00-21:00.
+func businessHours() (int, int) {
+	if start, end, ok := parseClockRange(os.Getenv("FLIGHT_BUSINESS_HOURS")); ok {
+		return start, end
+	}
+	start, end, _ := parseClockRange(defaultBusinessHours)
+	return start, end
+}
+
+// departsWithin reports whether the outbound and, for round trips, the
+// return leg both depart inside the window, local to their airports. A
+// departure time that cannot be parsed does not exclude the offer.
+func departsWithin(offer FlightOffer, start, end int) bool {
+	for _, segments := range [][]Segment{offer.Segments, offer.ReturnSegments} {
+		if len(segments) == 0 {
+			continue
+		}
+		if depart, ok := parseLocalTime(segments[0].DepartAt); ok && !inClockRange(minuteOfDay(depart), start, end) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRedeye treats an offer as a red-eye when its first segment departs
+// inside the night window (local to the origin), or when any segment is
+// airborne across local midnight, i.e. lands on a later local date than it
+// took off. An overnight spent on the ground at a connection is not a
+// red-eye by this definition.
+func isRedeye(offer FlightOffer, start, end int) bool {
+	if len(offer.Segments) == 0 {
+		return false
+	}
+	if depart, ok := parseLocalTime(offer.Segments[0].DepartAt); ok && inClockRange(minuteOfDay(depart), start, end) {
+		return true
+	}
+	for _, segment := range offer.Segments {
+		depart, okDepart := parseLocalTime(segment.DepartAt)
+		arrive, okArrive := parseLocalTime(segment.ArriveAt)
+		if okDepart && okArrive && arrive.Format(dateLayout) > depart.Format(dateLayout) {
+			return true
+		}
+	}
+	return false
+}
+
+func applyFilters(offers []FlightOffer, params SearchParams) []FlightOffer {
+	offers, _ = filterOffers(offers, params)
+	return offers
+}
+
+// filterOffers runs the active filters in order and counts, per filter,
+// the offers it removed from those that survived the earlier ones.
+func filterOffers(offers []FlightOffer, params SearchParams) ([]FlightOffer, map[string]int) {
+	filters := activeFilters(params)
+	if len(filters) == 0 {
+		return offers, nil
+	}
+	removed := make(map[string]int, len(filters))
+	for _, filter := range filters {
+		kept := offers[:0:0]
+		for _, offer := range offers {
+			if filter.keep(offer) {
+				kept = append(kept, offer)
+			}
+		}
+		removed[filter.name] = len(offers) - len(kept)
+		offers = kept
+	}
+	return offers, removed
+}
+
+// countRemoved adds a removal count for a step that prunes offers outside
+// activeFilters, such as mileage_only or best_per_daypart.
+func countRemoved(stats map[string]int, name string, before, after int) map[string]int {
+	if stats == nil {
+		stats = map[string]int{}
+	}
+	stats[name] = before - after
+	return stats
+}
+
+// normalizeFlightNumber turns "lh 0400" or "LH-400" into "LH400".
+func normalizeFlightNumber(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if len(value) < 3 {
+		return value
+	}
+
+	carrier, number := value[:2], strings.TrimLeft(value[2:], "0")
+	if number == "" {
+		number = "0"
+	}
+	return carrier + number
+}
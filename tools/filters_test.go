@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectionCountriesChecksReturnLeg(t *testing.T) {
+	offer := FlightOffer{
+		Origin:      "JFK",
+		Destination: "LHR",
+		Segments:    []Segment{{From: "JFK", To: "LHR"}},
+		ReturnSegments: []Segment{
+			{From: "LHR", To: "SVO"},
+			{From: "SVO", To: "JFK"},
+		},
+	}
+	params := SearchParams{AllowedConnectionCountries: []string{"GB"}}
+
+	kept, stats := filterOffers([]FlightOffer{offer}, params)
+	if len(kept) != 0 || stats["connection_countries"] != 1 {
+		t.Fatalf("return connection via SVO kept: %d offers, stats %v", len(kept), stats)
+	}
+
+	offer.ReturnSegments = []Segment{{From: "LHR", To: "JFK"}}
+	if kept := applyFilters([]FlightOffer{offer}, params); len(kept) != 1 {
+		t.Error("direct return dropped")
+	}
+}
+
+func TestFilterStatsCountsEveryStep(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	// The mock flies MK 07:10 and 13:05 and MX 19:30; MK is not part of
+	// any alliance, so mileage_only on aadvantage removes every offer.
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:         "SFO",
+		Destination:    "JFK",
+		DepartDate:     "2026-03-15",
+		MaxPrice:       10000,
+		MileageProgram: "aadvantage",
+		MileageOnly:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := outcome.Meta["filter_stats"].(map[string]int)
+	if stats["max_price"] != 0 || stats["mileage_only"] != 3 {
+		t.Errorf("filter_stats = %v, want max_price 0 and mileage_only 3", stats)
+	}
+
+	outcome, err = searchFlights(context.Background(), SearchParams{
+		Origin:         "SFO",
+		Destination:    "JFK",
+		DepartDate:     "2026-03-15",
+		FlexDays:       1,
+		BestPerDaypart: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = outcome.Meta["filter_stats"].(map[string]int)
+	if stats["best_per_daypart"] != 6 || len(outcome.Offers) != 3 {
+		t.Errorf("filter_stats = %v with %d offers, want best_per_daypart 6 and 3 offers", stats, len(outcome.Offers))
+	}
+}
+
+func TestFlightNumbersFilterNormalizesInput(t *testing.T) {
+	offers := []FlightOffer{
+		{OfferID: "lh400", FlightNumber: "LH400", Segments: []Segment{{FlightNumber: "LH400"}}},
+		{OfferID: "ua960", FlightNumber: "UA960", Segments: []Segment{{FlightNumber: "UA960"}, {FlightNumber: "LH4000"}}},
+		{OfferID: "via-lh400", FlightNumber: "UA8840", Segments: []Segment{{FlightNumber: "UA8840"}, {FlightNumber: "LH400"}}},
+	}
+
+	kept, stats := filterOffers(offers, SearchParams{FlightNumbers: []string{"lh 0400"}})
+	if len(kept) != 2 || kept[0].OfferID != "lh400" || kept[1].OfferID != "via-lh400" {
+		t.Errorf("kept = %v, want lh400 and via-lh400", kept)
+	}
+	if stats["flight_numbers"] != 1 {
+		t.Errorf("filter_stats = %v, want flight_numbers 1", stats)
+	}
+
+	roundTrips := []FlightOffer{
+		{OfferID: "lh401-back", FlightNumber: "LH400", Segments: []Segment{{FlightNumber: "LH400"}}, ReturnSegments: []Segment{{FlightNumber: "LH401"}}},
+		{OfferID: "ua-back", FlightNumber: "LH400", Segments: []Segment{{FlightNumber: "LH400"}}, ReturnSegments: []Segment{{FlightNumber: "UA961"}}},
+	}
+	if kept := applyFilters(roundTrips, SearchParams{FlightNumbers: []string{"LH 401"}}); len(kept) != 1 || kept[0].OfferID != "lh401-back" {
+		t.Errorf("return flight LH401: kept %v, want lh401-back", kept)
+	}
+
+	for input, want := range map[string]string{"lh 0400": "LH400", "LH-400": "LH400", " ua960 ": "UA960", "BA000": "BA0"} {
+		if got := normalizeFlightNumber(input); got != want {
+			t.Errorf("normalizeFlightNumber(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// departingAt builds a one-segment offer departing at the local time clock
+// on 2026-11-10 and arriving two hours later.
+func departingAt(id, clock string) FlightOffer {
+	depart, _ := time.Parse("2006-01-02T15:04", "2026-11-10T"+clock)
+	arrive := depart.Add(2 * time.Hour)
+	return FlightOffer{OfferID: id, Segments: []Segment{{
+		DepartAt: depart.Format("2006-01-02T15:04:05"),
+		ArriveAt: arrive.Format("2006-01-02T15:04:05"),
+	}}}
+}
+
+func TestExcludeRedeyeDropsEarlyMorningDeparture(t *testing.T) {
+	offers := []FlightOffer{departingAt("early", "01:30"), departingAt("morning", "08:15")}
+
+	kept, stats := filterOffers(offers, SearchParams{ExcludeRedeye: true})
+	if len(kept) != 1 || kept[0].OfferID != "morning" {
+		t.Errorf("kept = %v, want only the 08:15 departure", kept)
+	}
+	if stats["redeye"] != 1 {
+		t.Errorf("filter_stats = %v, want redeye 1", stats)
+	}
+}
+
+func TestMaxPriceRoundingAtBoundary(t *testing.T) {
+	offers := []FlightOffer{
+		{OfferID: "500.40", Price: "500.40", Currency: "EUR"},
+		{OfferID: "500.60", Price: "500.60", Currency: "EUR"},
+		{OfferID: "499.99", Price: "499.99", Currency: "EUR"},
+	}
+	tests := []struct {
+		rounding string
+		want     []string
+	}{
+		{"ceil", []string{"499.99"}},
+		{"", []string{"499.99"}},
+		{"floor", []string{"500.40", "500.60", "499.99"}},
+		{"nearest", []string{"500.40", "499.99"}},
+	}
+	for _, tt := range tests {
+		kept := applyFilters(offers, SearchParams{MaxPrice: 500, Currency: "EUR", PriceRounding: tt.rounding})
+		var got []string
+		for _, offer := range kept {
+			got = append(got, offer.OfferID)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("price_rounding %q: kept %v, want %v", tt.rounding, got, tt.want)
+		}
+	}
+
+	t.Setenv("FLIGHT_PRICE_ROUNDING", "floor")
+	if kept := applyFilters(offers, SearchParams{MaxPrice: 500}); len(kept) != 3 {
+		t.Errorf("FLIGHT_PRICE_ROUNDING=floor: kept %d offers, want 3", len(kept))
+	}
+}
+
+func TestBookableWithinDaysDropsNearDeadline(t *testing.T) {
+	previous := now
+	now = func() time.Time { return time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = previous })
+
+	offers := []FlightOffer{
+		{OfferID: "tomorrow", LastTicketingDate: "2026-11-02"},
+		{OfferID: "in-three-days", LastTicketingDate: "2026-11-04"},
+		{OfferID: "no-deadline"},
+	}
+	kept, stats := filterOffers(offers, SearchParams{BookableWithinDays: 3})
+	if len(kept) != 2 || kept[0].OfferID != "in-three-days" || kept[1].OfferID != "no-deadline" {
+		t.Errorf("kept = %v, want in-three-days and no-deadline", kept)
+	}
+	if stats["bookable_within_days"] != 1 {
+		t.Errorf("filter_stats = %v, want bookable_within_days 1", stats)
+	}
+
+	t.Setenv("FLIGHT_MISSING_TICKETING_DATE", "drop")
+	if kept := applyFilters(offers, SearchParams{BookableWithinDays: 3}); len(kept) != 1 {
+		t.Errorf("missing deadline with drop: kept %v, want in-three-days only", kept)
+	}
+}
+
+func TestPerAirportMinimumConnectionTime(t *testing.T) {
+	connectingAt := func(id, airport string) FlightOffer {
+		return FlightOffer{OfferID: id, Segments: []Segment{
+			{From: "BOS", To: airport, DepartAt: "2026-11-10T08:00:00", ArriveAt: "2026-11-10T10:00:00"},
+			{From: airport, To: "MIA", DepartAt: "2026-11-10T10:45:00", ArriveAt: "2026-11-10T13:00:00"},
+		}}
+	}
+	offers := []FlightOffer{connectingAt("atl", "ATL"), connectingAt("ord", "ORD")}
+	params := SearchParams{ExcludeShortLayovers: true}
+
+	if kept := applyFilters(offers, params); len(kept) != 0 {
+		t.Errorf("45-minute connections under the 60-minute default: kept %v", kept)
+	}
+
+	t.Setenv("FLIGHT_MIN_CONNECTION_AIRPORTS", `{"atl":40}`)
+	kept, stats := filterOffers(offers, params)
+	if len(kept) != 1 || kept[0].OfferID != "atl" || stats["short_layovers"] != 1 {
+		t.Errorf("ATL MCT 40: kept %v with stats %v, want atl only", kept, stats)
+	}
+}
+
+func TestBusinessHoursOnlyDropsPreDawnDeparture(t *testing.T) {
+	offers := []FlightOffer{departingAt("pre-dawn", "05:15"), departingAt("midday", "12:00"), departingAt("late", "22:30")}
+
+	kept, stats := filterOffers(offers, SearchParams{BusinessHoursOnly: true})
+	if len(kept) != 1 || kept[0].OfferID != "midday" || stats["business_hours"] != 2 {
+		t.Errorf("kept %v with stats %v, want midday only", kept, stats)
+	}
+
+	t.Setenv("FLIGHT_BUSINESS_HOURS", "05:00-23:00")
+	if kept := applyFilters(offers, SearchParams{BusinessHoursOnly: true}); len(kept) != 3 {
+		t.Errorf("FLIGHT_BUSINESS_HOURS=05:00-23:00: kept %v, want all 3", kept)
+	}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +17,12 @@ import (
 	agk "github.com/agenticgokit/agenticgokit/v1beta"
 )
 
+// SchemaVersion identifies the shape of the tool payload. It is bumped
+// whenever result fields change in a way that breaks existing parsers.
+const SchemaVersion = "2"
+
+const defaultMaxResponseBytes = 10 << 20
+
 type flightSearchTool struct{}
 
 var (
@@ -36,16 +44,28 @@ func (t *flightSearchTool) Description() string {
 }
 
 func (t *flightSearchTool) Execute(ctx context.Context, args map[string]interface{}) (*agk.ToolResult, error) {
-	query := buildQuery(args)
-	results, source, err := searchFlights(ctx, args)
+	params := parseSearchParams(args)
+	query := buildQuery(params)
+	outcome, err := searchFlights(ctx, params)
 	if err != nil {
-		return &agk.ToolResult{Success: false, Error: err.Error()}, err
+		return errorResult(query, err), err
 	}
 
 	payload := map[string]interface{}{
-		"query":   query,
-		"results": results,
-		"source":  source,
+		"schema_version": SchemaVersion,
+		"query":          query,
+		"results":        outcome.Offers,
+		"source":         outcome.Source,
+		"meta":           outcome.Meta,
+	}
+	if len(outcome.DebugCurl) > 0 {
+		payload["debug_curl"] = outcome.DebugCurl
+	}
+	if outcome.NearbySuggestion != nil {
+		payload["nearby_suggestion"] = outcome.NearbySuggestion
+	}
+	if len(outcome.Offers) == 0 {
+		payload["error_code"] = ErrorCodeNoResults
 	}
 
 	jsonBytes, err := json.Marshal(payload)
@@ -56,6 +76,22 @@ func (t *flightSearchTool) Execute(ctx context.Context, args map[string]interfac
 	return &agk.ToolResult{Success: true, Content: string(jsonBytes)}, nil
 }
 
+// errorResult carries the message in Error and, for agents that branch on
+// failure type, a payload with a stable error_code.
+func errorResult(query string, err error) *agk.ToolResult {
+	payload := map[string]interface{}{
+		"schema_version": SchemaVersion,
+		"query":          query,
+		"error":          err.Error(),
+		"error_code":     ErrorCode(err),
+	}
+	result := &agk.ToolResult{Success: false, Error: err.Error()}
+	if jsonBytes, marshalErr := json.Marshal(payload); marshalErr == nil {
+		result.Content = string(jsonBytes)
+	}
+	return result
+}
+
 func (t *flightSearchTool) JSONSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
@@ -88,121 +124,611 @@ func (t *flightSearchTool) JSONSchema() map[string]interface{} {
 				"type":        "number",
 				"description": "Maximum price",
 			},
+			"budget": map[string]interface{}{
+				"type":        "number",
+				"description": "Soft price limit: offers above it are kept but flagged within_budget false",
+			},
 			"currency": map[string]interface{}{
 				"type":        "string",
 				"description": "Currency code",
 			},
+			"price_rounding": map[string]interface{}{
+				"type":        "string",
+				"description": "How fares are rounded before the max_price check: ceil (default), floor or nearest",
+			},
+			"flex_days": map[string]interface{}{
+				"type":        "number",
+				"description": "Also search this many days before and after the requested dates",
+			},
+			"nonstop": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only search nonstop flights",
+			},
+			"include_nearby": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also search airports in the same metro area or close by",
+			},
+			"suggest_nearby": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Probe nearby airports and report a markedly cheaper one without changing the results",
+			},
+			"min_results": map[string]interface{}{
+				"type":        "number",
+				"description": "Broaden the search (allow stops, flex dates, nearby airports) until at least this many offers match",
+			},
+			"included_bags_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Ask Amadeus for fares that include checked bags only",
+			},
+			"bags_included": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Drop returned offers where any flight lacks an included checked bag",
+			},
+			"relax_if_empty": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Retry without max_price when nothing matches",
+			},
+			"mileage_program": map[string]interface{}{
+				"type":        "string",
+				"description": "Frequent flyer program to rank eligible carriers first (e.g. aadvantage, mileageplus, flying_blue)",
+			},
+			"mileage_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Drop offers that do not earn on mileage_program",
+			},
+			"preferred_alliance": map[string]interface{}{
+				"type":        "string",
+				"description": "Rank offers flown entirely within this alliance first (star_alliance, oneworld, skyteam)",
+			},
+			"sort_by": map[string]interface{}{
+				"type":        "string",
+				"description": "Order results by price (default), duration, stops or value (weighted price, duration and stops)",
+			},
+			"score_detail": map[string]interface{}{
+				"type":        "boolean",
+				"description": "With sort_by value, attach each offer's sub-scores and weighted score",
+			},
+			"best_per_daypart": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return only the best offer departing in each of night, morning, afternoon and evening",
+			},
+			"explain": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Attach a ranking_reason to the top offer",
+			},
+			"flight_numbers": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only keep offers with a segment, on either leg, on one of these flight numbers (e.g. LH400)",
+			},
+			"exclude_redeye": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Drop overnight and red-eye departures",
+			},
+			"no_overnight_stay": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Drop offers that need a night at a connection airport",
+			},
+			"business_hours_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Keep only offers departing within business hours (FLIGHT_BUSINESS_HOURS, default 06:00-21:00 local)",
+			},
+			"exclude_short_layovers": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Drop offers with a connection shorter than the airport's minimum connection time",
+			},
+			"allowed_connection_countries": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "ISO country codes connections on either leg may pass through (origin and destination countries are always allowed)",
+			},
+			"strict_connection_countries": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also drop offers connecting at airports whose country is unknown",
+			},
+			"bookable_within_days": map[string]interface{}{
+				"type":        "number",
+				"description": "Drop fares whose last ticketing date is fewer than this many days away",
+			},
+			"route_style": map[string]interface{}{
+				"type":        "string",
+				"description": "Route summary style: full (every airport, default) or compact (endpoints and stop count)",
+			},
+			"include_coords": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Add airport coordinates for mapping",
+			},
+			"diff_from_cache": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Search fresh and report offers added, removed or repriced since the last identical search",
+			},
+			"debug_curl": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include cURL commands reproducing each Amadeus request (token redacted)",
+			},
 		},
 		"required": []string{"origin", "destination", "depart_date"},
 	}
 }
 
-func buildQuery(args map[string]interface{}) string {
-	origin := getString(args, "origin")
-	destination := getString(args, "destination")
-	departDate := getString(args, "depart_date")
-	returnDate := getString(args, "return_date")
-	passengers := getNumber(args, "passengers")
-	cabin := getString(args, "cabin")
-	maxPrice := getNumber(args, "max_price")
-	currency := getString(args, "currency")
-
-	parts := []string{fmt.Sprintf("%s → %s", origin, destination)}
-	if departDate != "" {
-		parts = append(parts, "depart "+departDate)
+func buildQuery(params SearchParams) string {
+	parts := []string{fmt.Sprintf("%s → %s", params.Origin, params.Destination)}
+	if params.DepartDate != "" {
+		parts = append(parts, "depart "+params.DepartDate)
 	}
-	if returnDate != "" {
-		parts = append(parts, "return "+returnDate)
+	if params.ReturnDate != "" {
+		parts = append(parts, "return "+params.ReturnDate)
 	}
-	if passengers > 0 {
-		parts = append(parts, fmt.Sprintf("%d pax", int(passengers)))
+	if params.FlexDays > 0 {
+		parts = append(parts, fmt.Sprintf("±%d days", params.FlexDays))
 	}
-	if cabin != "" {
-		parts = append(parts, strings.ToLower(cabin))
+	if params.Passengers > 0 {
+		parts = append(parts, fmt.Sprintf("%d pax", params.Passengers))
 	}
-	if maxPrice > 0 {
-		parts = append(parts, fmt.Sprintf("max %0.0f %s", maxPrice, currency))
+	if params.Cabin != "" {
+		parts = append(parts, strings.ToLower(params.Cabin))
+	}
+	if params.MaxPrice > 0 {
+		parts = append(parts, fmt.Sprintf("max %0.0f %s", params.MaxPrice, params.Currency))
 	}
 
 	return strings.Join(parts, ", ")
 }
 
-func searchFlights(ctx context.Context, args map[string]interface{}) ([]map[string]interface{}, string, error) {
+type searchOutcome struct {
+	Offers    []FlightOffer
+	Source    string
+	Meta      map[string]interface{}
+	DebugCurl []string
+
+	NearbySuggestion map[string]interface{}
+}
+
+type offerFetcher interface {
+	fetchOffers(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error)
+}
+
+type amadeusClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+
+	mu    sync.Mutex
+	token string
+}
+
+type searchRun struct {
+	fetcher offerFetcher
+	source  string
+	sandbox bool
+	budget  *requestBudget
+	cached  bool
+	relaxed []string
+	params  SearchParams
+	window  []searchRequest
+	baseURL string
+	curls   []string
+	diff    map[string]interface{}
+	sem     chan struct{}
+
+	broadened map[string]interface{}
+}
+
+func searchFlights(ctx context.Context, params SearchParams) (*searchOutcome, error) {
+	return searchFlightsWithLimiter(ctx, params, newLimiter())
+}
+
+// searchFlightsWithLimiter runs one search whose Amadeus requests share
+// sem with any other search given the same limiter.
+func searchFlightsWithLimiter(ctx context.Context, params SearchParams, sem chan struct{}) (*searchOutcome, error) {
+	if err := validateSearchParams(params); err != nil {
+		return nil, err
+	}
+
+	run, err := newSearchRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run.params = params
+	run.sem = sem
+
+	offers, err := run.search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(offers) == 0 && params.RelaxIfEmpty && params.MaxPrice > 0 {
+		if run.budget.exhausted() {
+			run.budget.skip("relax_if_empty")
+		} else {
+			params.MaxPrice = 0
+			offers, err = run.search(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			run.relaxed = append(run.relaxed, "max_price")
+		}
+	}
+	offers, params, err = run.broaden(ctx, params, offers)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := offers
+	offers, filterStats := filterOffers(offers, params)
+	sortOffers(offers, normalizeSortBy(params.SortBy))
+	if !params.ScoreDetail {
+		for i := range offers {
+			offers[i].ScoreDetail = nil
+		}
+	}
+	before := len(offers)
+	offers, err = applyPreferences(offers, params)
+	if err != nil {
+		return nil, err
+	}
+	if params.MileageOnly && params.MileageProgram != "" {
+		filterStats = countRemoved(filterStats, "mileage_only", before, len(offers))
+	}
+	if params.BestPerDaypart {
+		before = len(offers)
+		offers = bestPerDaypart(offers)
+		filterStats = countRemoved(filterStats, "best_per_daypart", before, len(offers))
+	}
+	if params.Explain && len(offers) > 0 {
+		offers[0].RankingReason = rankingReason(offers[0], params)
+	}
+
+	var suggestion map[string]interface{}
+	if params.SuggestNearby && !params.IncludeNearby {
+		suggestion = run.suggestNearby(ctx, params, offers)
+	}
+
+	enrichOffers(offers, params)
+	offers, err = runPostSearchHook(ctx, offers)
+	if err != nil {
+		return nil, fmt.Errorf("post-search hook failed: %w", err)
+	}
+	assignRefs(offers)
+
+	// History is keyed by the effective Amadeus search and fed the offers
+	// it returned, before any post-search filter, so restricted and
+	// unrestricted searches share one comparable minimum. Cached offers
+	// were observed when they were fetched and are not counted again.
+	historyKey := run.source + "|" + SearchCacheKey(params)
+	previous, hasHistory := priceHistory.get(historyKey)
+	if hasHistory {
+		flagDeals(offers, previous)
+		runPriceAlertHook(historyKey, previous, fetched)
+	}
+	if !run.cached {
+		priceHistory.observe(historyKey, fetched)
+	}
+
+	meta := run.meta()
+	addPriceRange(meta, offers)
+	addCabinSummary(meta, offers)
+	if filterStats != nil {
+		meta["filter_stats"] = filterStats
+	}
+	if warnings := cabinWarnings(offers, params.Cabin); len(warnings) > 0 {
+		meta["warnings"] = warnings
+	}
+	if hasHistory {
+		meta["historical_min"] = map[string]interface{}{
+			"price":        previous.Min,
+			"currency":     previous.Currency,
+			"observations": previous.Observations,
+		}
+	}
+
+	return &searchOutcome{
+		Offers:           offers,
+		Source:           run.source,
+		Meta:             meta,
+		DebugCurl:        run.curls,
+		NearbySuggestion: suggestion,
+	}, nil
+}
+
+func newSearchRun(ctx context.Context) (*searchRun, error) {
+	if mockEnabled() {
+		return &searchRun{fetcher: mockFetcher{}, source: "mock", budget: newRequestBudget()}, nil
+	}
+
 	clientID := os.Getenv("AMADEUS_CLIENT_ID")
 	clientSecret := os.Getenv("AMADEUS_CLIENT_SECRET")
 	if clientID == "" || clientSecret == "" {
-		return nil, "amadeus", fmt.Errorf("missing AMADEUS_CLIENT_ID or AMADEUS_CLIENT_SECRET")
+		return nil, ErrMissingCredentials
 	}
 
-	baseURL := os.Getenv("AMADEUS_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://test.api.amadeus.com"
-	}
+	baseURL := resolveBaseURL()
 
 	token, err := getAccessToken(ctx, baseURL, clientID, clientSecret)
 	if err != nil {
-		return nil, "amadeus", err
+		return nil, err
+	}
+
+	return &searchRun{
+		fetcher: &amadeusClient{baseURL: baseURL, clientID: clientID, clientSecret: clientSecret, token: token},
+		source:  "amadeus",
+		sandbox: isSandboxURL(baseURL),
+		baseURL: baseURL,
+		budget:  newRequestBudget(),
+	}, nil
+}
+
+func (r *searchRun) search(ctx context.Context, params SearchParams) ([]FlightOffer, error) {
+	planned := planRequests(params)
+	key := r.source + "|" + SearchCacheKey(params)
+	if !params.DiffFromCache {
+		if offers, ok := resultCache.get(key); ok {
+			r.cached = true
+			r.window = mergeWindow(r.window, planned)
+			return offers, r.addCurls(params, planned)
+		}
+	}
+
+	requests := r.budget.take(planned)
+	r.window = mergeWindow(r.window, requests)
+	if err := r.addCurls(params, requests); err != nil {
+		return nil, err
+	}
+	r.budget.spend(len(requests))
+
+	offers, err := runRequests(ctx, r.fetcher, r.sem, params, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.DiffFromCache {
+		if previous, ok := resultCache.previous(key); ok {
+			r.diff = diffOffers(previous, offers)
+		} else {
+			r.diff = map[string]interface{}{"previous_at": nil}
+		}
+	}
+	// A fan-out trimmed by the request budget covers only part of the
+	// search, so it must not be served later as the full result for key.
+	if len(requests) == len(planned) {
+		resultCache.put(key, offers)
+	}
+	return offers, nil
+}
+
+func (r *searchRun) addCurls(params SearchParams, requests []searchRequest) error {
+	if !params.DebugCurl || r.baseURL == "" {
+		return nil
+	}
+	for _, req := range requests {
+		command, err := debugCurl(r.baseURL, params, req)
+		if err != nil {
+			return err
+		}
+		r.curls = append(r.curls, command)
+	}
+	return nil
+}
+
+func (r *searchRun) meta() map[string]interface{} {
+	meta := map[string]interface{}{
+		"requests":      r.budget.used,
+		"cached":        r.cached,
+		"confidence":    r.confidence(),
+		"search_window": searchWindow(r.params, r.window),
+	}
+	if trimmed := r.budget.trimmed(r.window); trimmed != nil {
+		meta["trimmed"] = trimmed
+	}
+	if len(r.relaxed) > 0 {
+		meta["relaxed"] = r.relaxed
+	}
+	if r.broadened != nil {
+		meta["broadened"] = r.broadened
+	}
+	if r.diff != nil {
+		meta["diff_from_cache"] = r.diff
+	}
+	return meta
+}
+
+// confidence reports the least trustworthy signal that applies, in order:
+// mock, sandbox, relaxed, cached, live_exact.
+func (r *searchRun) confidence() string {
+	switch {
+	case r.source == "mock":
+		return "mock"
+	case r.sandbox:
+		return "sandbox"
+	case len(r.relaxed) > 0:
+		return "relaxed"
+	case r.cached:
+		return "cached"
+	default:
+		return "live_exact"
+	}
+}
+
+func (c *amadeusClient) fetchOffers(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+	body, err := c.send(ctx, "flight offers", func(token string) (*http.Request, error) {
+		return newOffersRequest(ctx, c.baseURL, token, params, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseAmadeusOffers(body)
+}
+
+// send retries a 401 with a fresh token up to AMADEUS_MAX_AUTH_RETRIES
+// times. Other failures are returned as is, so a request is sent at most
+// 1+AMADEUS_MAX_AUTH_RETRIES times.
+func (c *amadeusClient) send(ctx context.Context, name string, build func(token string) (*http.Request, error)) ([]byte, error) {
+	retries := maxAuthRetries()
+	for attempt := 0; ; attempt++ {
+		token := c.currentToken()
+		request, err := build(token)
+		if err != nil {
+			return nil, err
+		}
+		status, body, err := doRequest(request)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusUnauthorized && attempt < retries {
+			if err := c.refreshToken(ctx, token); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if status < 200 || status >= 300 {
+			return nil, &UpstreamError{Request: name, StatusCode: status}
+		}
+		return body, nil
+	}
+}
+
+func doRequest(request *http.Request) (int, []byte, error) {
+	httpClient := &http.Client{Timeout: 25 * time.Second}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return 0, nil, err
 	}
+	defer resp.Body.Close()
 
+	body, err := readResponseBody(resp)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+func (c *amadeusClient) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// refreshToken replaces stale with a newly issued token. Concurrent
+// requests that saw the same stale token share one refresh.
+func (c *amadeusClient) refreshToken(ctx context.Context, stale string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != stale {
+		return nil
+	}
+
+	tokenMu.Lock()
+	if accessToken == stale {
+		accessToken = ""
+		tokenExpiresAt = time.Time{}
+	}
+	tokenMu.Unlock()
+
+	token, err := getAccessToken(ctx, c.baseURL, c.clientID, c.clientSecret)
+	if err != nil {
+		return err
+	}
+	c.token = token
+	return nil
+}
+
+func maxAuthRetries() int {
+	if n := envInt("AMADEUS_MAX_AUTH_RETRIES", 1); n >= 0 {
+		return n
+	}
+	return 1
+}
+
+func newOffersRequest(ctx context.Context, baseURL, token string, params SearchParams, req searchRequest) (*http.Request, error) {
 	query := url.Values{}
-	query.Set("originLocationCode", getString(args, "origin"))
-	query.Set("destinationLocationCode", getString(args, "destination"))
-	query.Set("departureDate", getString(args, "depart_date"))
-	if returnDate := getString(args, "return_date"); returnDate != "" {
-		query.Set("returnDate", returnDate)
+	query.Set("originLocationCode", req.Origin)
+	query.Set("destinationLocationCode", req.Destination)
+	query.Set("departureDate", req.DepartDate)
+	if req.ReturnDate != "" {
+		query.Set("returnDate", req.ReturnDate)
 	}
-	adults := getNumber(args, "passengers")
+	adults := params.Passengers
 	if adults <= 0 {
 		adults = 1
 	}
-	query.Set("adults", fmt.Sprintf("%d", int(adults)))
-	if cabin := strings.ToUpper(getString(args, "cabin")); cabin != "" {
+	query.Set("adults", fmt.Sprintf("%d", adults))
+	if cabin := strings.ToUpper(params.Cabin); cabin != "" {
 		query.Set("travelClass", cabin)
 	}
-	if currency := getString(args, "currency"); currency != "" {
-		query.Set("currencyCode", currency)
+	if params.Currency != "" {
+		query.Set("currencyCode", params.Currency)
+	}
+	if params.MaxPrice > 0 {
+		query.Set("maxPrice", fmt.Sprintf("%0.0f", params.MaxPrice))
 	}
-	if maxPrice := getNumber(args, "max_price"); maxPrice > 0 {
-		query.Set("maxPrice", fmt.Sprintf("%0.0f", maxPrice))
+	query.Set("nonStop", strconv.FormatBool(params.NonStop))
+	if params.IncludedBagsOnly {
+		query.Set("includedCheckedBagsOnly", "true")
 	}
-	query.Set("nonStop", "false")
 
 	endpoint := fmt.Sprintf("%s/v2/shopping/flight-offers?%s", baseURL, query.Encode())
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, "amadeus", err
+		return nil, err
 	}
 	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept-Encoding", "gzip")
+	return request, nil
+}
 
-	client := &http.Client{Timeout: 25 * time.Second}
-	resp, err := client.Do(request)
-	if err != nil {
-		return nil, "amadeus", err
+// readResponseBody reads resp.Body, decompressing it when the server
+// answered with Content-Encoding: gzip. Setting Accept-Encoding ourselves
+// disables net/http's transparent decompression, so this must be used for
+// every request that asks for gzip. The decompressed size is capped at
+// FLIGHT_MAX_RESPONSE_BYTES (default 10MB) so a pathological response
+// cannot exhaust memory.
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	var reader io.Reader = resp.Body
+	if strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("amadeus response is not valid gzip: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	limit := maxResponseBytes()
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
 	if err != nil {
-		return nil, "amadeus", err
+		return nil, err
 	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, "amadeus", fmt.Errorf("amadeus flight offers request failed: %s", resp.Status)
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("amadeus response exceeds %d bytes (FLIGHT_MAX_RESPONSE_BYTES)", limit)
 	}
+	return body, nil
+}
 
-	parsed, err := parseAmadeusOffers(body)
-	if err != nil {
-		return nil, "amadeus", err
+func maxResponseBytes() int64 {
+	if n := envInt("FLIGHT_MAX_RESPONSE_BYTES", defaultMaxResponseBytes); n > 0 {
+		return int64(n)
 	}
+	return defaultMaxResponseBytes
+}
+
+// TokenStatus reports whether a cached Amadeus token would be reused by the
+// next search and when it expires. It never triggers a refresh and never
+// exposes the token itself, so it is safe to call from health endpoints.
+func TokenStatus() (valid bool, expiresAt time.Time) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	return tokenUsable(), tokenExpiresAt
+}
 
-	return parsed, "amadeus", nil
+// tokenUsable must be called with tokenMu held.
+func tokenUsable() bool {
+	return accessToken != "" && time.Now().Before(tokenExpiresAt.Add(-30*time.Second))
 }
 
 func getAccessToken(ctx context.Context, baseURL, clientID, clientSecret string) (string, error) {
 	tokenMu.Lock()
 	defer tokenMu.Unlock()
 
-	if accessToken != "" && time.Now().Before(tokenExpiresAt.Add(-30*time.Second)) {
+	if tokenUsable() {
 		return accessToken, nil
 	}
 
@@ -224,12 +750,12 @@ func getAccessToken(ctx context.Context, baseURL, clientID, clientSecret string)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponseBody(resp)
 	if err != nil {
 		return "", err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("amadeus token request failed: %s", resp.Status)
+		return "", &UpstreamError{Request: "token", StatusCode: resp.StatusCode}
 	}
 
 	var tokenResp struct {
@@ -255,37 +781,182 @@ func getAccessToken(ctx context.Context, baseURL, clientID, clientSecret string)
 	return accessToken, nil
 }
 
-func parseAmadeusOffers(body []byte) ([]map[string]interface{}, error) {
+type FlightOffer struct {
+	Ref            string `json:"ref"`
+	OfferID        string `json:"offer_id"`
+	AmadeusOfferID string `json:"amadeus_offer_id,omitempty"`
+	Request        string `json:"request,omitempty"`
+
+	Airline      string `json:"airline"`
+	AirlineName  string `json:"airline_name,omitempty"`
+	FlightNumber string `json:"flight_number"`
+	Origin       string `json:"origin"`
+	Destination  string `json:"destination"`
+	Route        string `json:"route"`
+	DepartDate   string `json:"depart_date"`
+	DepartTime   string `json:"depart_time"`
+	ArriveTime   string `json:"arrive_time"`
+
+	DepartAtRFC3339 string `json:"depart_at_rfc3339,omitempty"`
+	ArriveAtRFC3339 string `json:"arrive_at_rfc3339,omitempty"`
+	// TimesNaive marks the *_rfc3339 fields as airport-local without an
+	// offset, which is how Amadeus usually reports times.
+	TimesNaive bool `json:"times_naive,omitempty"`
+
+	Duration string `json:"duration"`
+	Stops    int    `json:"stops"`
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+	Deal     bool   `json:"deal,omitempty"`
+	Daypart  string `json:"daypart,omitempty"`
+
+	WithinBudget *bool `json:"within_budget,omitempty"`
+
+	// PriceGuaranteed is false for search results, whose fares Amadeus may
+	// serve from cache, and true once PriceOffer has confirmed the price.
+	PriceGuaranteed bool `json:"price_guaranteed"`
+
+	Cabin               string `json:"cabin,omitempty"`
+	CabinMatchesRequest *bool  `json:"cabin_matches_request,omitempty"`
+
+	Segments       []Segment `json:"segments"`
+	ReturnSegments []Segment `json:"return_segments,omitempty"`
+	Layovers       []Layover `json:"layovers,omitempty"`
+	LayoverRatio   *float64  `json:"layover_ratio,omitempty"`
+
+	RequiresOvernightStay bool   `json:"requires_overnight_stay,omitempty"`
+	OvernightAirport      string `json:"overnight_airport,omitempty"`
+	OvernightCity         string `json:"overnight_city,omitempty"`
+
+	LayoverExperience *LayoverExperience `json:"layover_experience,omitempty"`
+	LegroomHint       *LegroomHint       `json:"legroom_hint,omitempty"`
+
+	LastTicketingDate string `json:"last_ticketing_date,omitempty"`
+
+	CO2Kg          *float64 `json:"co2_kg,omitempty"`
+	CO2RoundTripKg *float64 `json:"co2_round_trip_kg,omitempty"`
+	OffsetCost     *float64 `json:"offset_cost,omitempty"`
+	OffsetCurrency string   `json:"offset_currency,omitempty"`
+
+	RankingReason string       `json:"ranking_reason,omitempty"`
+	ScoreDetail   *ScoreDetail `json:"score_detail,omitempty"`
+
+	OriginCoords      *Coordinates           `json:"origin_coords,omitempty"`
+	DestinationCoords *Coordinates           `json:"destination_coords,omitempty"`
+	ConnectionCoords  map[string]Coordinates `json:"connection_coords,omitempty"`
+
+	TransitVisaWarning []string `json:"transit_visa_warning,omitempty"`
+
+	AllSameCarrier  bool `json:"all_same_carrier"`
+	AllSameAlliance bool `json:"all_same_alliance"`
+
+	// Extra holds integrator annotations added by a PostSearchHook.
+	Extra map[string]interface{} `json:"extra,omitempty"`
+
+	// raw is the Amadeus offer as returned by search, kept for PriceOffer.
+	raw json.RawMessage
+}
+
+type Segment struct {
+	Carrier          string   `json:"carrier"`
+	FlightNumber     string   `json:"flight_number"`
+	OperatingCarrier string   `json:"operating_carrier,omitempty"`
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	DepartAt         string   `json:"depart_at"`
+	ArriveAt         string   `json:"arrive_at"`
+	CO2Kg            *float64 `json:"co2_kg,omitempty"`
+	Cabin            string   `json:"cabin,omitempty"`
+	CheckedBags      int      `json:"checked_bags"`
+	Aircraft         string   `json:"aircraft,omitempty"`
+	AircraftName     string   `json:"aircraft_name,omitempty"`
+}
+
+type rawSegment struct {
+	ID          string `json:"id"`
+	CarrierCode string `json:"carrierCode"`
+	Number      string `json:"number"`
+	Operating   struct {
+		CarrierCode string `json:"carrierCode"`
+	} `json:"operating"`
+	Departure struct {
+		IataCode string `json:"iataCode"`
+		At       string `json:"at"`
+	} `json:"departure"`
+	Arrival struct {
+		IataCode string `json:"iataCode"`
+		At       string `json:"at"`
+	} `json:"arrival"`
+	Aircraft struct {
+		Code string `json:"code"`
+	} `json:"aircraft"`
+	CO2Emissions []co2Emission `json:"co2Emissions"`
+}
+
+func convertSegments(segments []rawSegment, cabins map[string]string, bags map[string]int, aircraft map[string]string) []Segment {
+	converted := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		converted = append(converted, Segment{
+			Carrier:          segment.CarrierCode,
+			FlightNumber:     strings.TrimSpace(segment.CarrierCode + segment.Number),
+			OperatingCarrier: segment.Operating.CarrierCode,
+			From:             segment.Departure.IataCode,
+			To:               segment.Arrival.IataCode,
+			DepartAt:         segment.Departure.At,
+			ArriveAt:         segment.Arrival.At,
+			CO2Kg:            emissionsKg(segment.CO2Emissions),
+			Cabin:            cabins[segment.ID],
+			CheckedBags:      bags[segment.ID],
+			Aircraft:         segment.Aircraft.Code,
+			AircraftName:     aircraft[segment.Aircraft.Code],
+		})
+	}
+	return converted
+}
+
+type rawOffer struct {
+	ID                string `json:"id"`
+	LastTicketingDate string `json:"lastTicketingDate"`
+	Price             struct {
+		Total    string `json:"total"`
+		Currency string `json:"currency"`
+	} `json:"price"`
+	TravelerPricings []struct {
+		FareDetailsBySegment []struct {
+			SegmentID           string `json:"segmentId"`
+			Cabin               string `json:"cabin"`
+			IncludedCheckedBags struct {
+				Quantity int     `json:"quantity"`
+				Weight   float64 `json:"weight"`
+			} `json:"includedCheckedBags"`
+		} `json:"fareDetailsBySegment"`
+	} `json:"travelerPricings"`
+	Itineraries []struct {
+		Duration string       `json:"duration"`
+		Segments []rawSegment `json:"segments"`
+	} `json:"itineraries"`
+}
+
+func parseAmadeusOffers(body []byte) ([]FlightOffer, error) {
+	// Offers stay raw until decoded one by one so each keeps its original
+	// JSON for PriceOffer without a second pass over the body.
 	var raw struct {
-		Data []struct {
-			Price struct {
-				Total    string `json:"total"`
-				Currency string `json:"currency"`
-			} `json:"price"`
-			Itineraries []struct {
-				Duration string `json:"duration"`
-				Segments []struct {
-					CarrierCode string `json:"carrierCode"`
-					Number      string `json:"number"`
-					Departure   struct {
-						IataCode string `json:"iataCode"`
-						At       string `json:"at"`
-					} `json:"departure"`
-					Arrival struct {
-						IataCode string `json:"iataCode"`
-						At       string `json:"at"`
-					} `json:"arrival"`
-				} `json:"segments"`
-			} `json:"itineraries"`
-		} `json:"data"`
+		Data         []json.RawMessage `json:"data"`
+		Dictionaries json.RawMessage   `json:"dictionaries"`
 	}
 
 	if err := json.Unmarshal(body, &raw); err != nil {
 		return nil, err
 	}
 
-	results := make([]map[string]interface{}, 0, len(raw.Data))
-	for _, offer := range raw.Data {
+	dicts := parseDictionaries(raw.Dictionaries)
+
+	results := make([]FlightOffer, 0, len(raw.Data))
+	for _, data := range raw.Data {
+		var offer rawOffer
+		if err := json.Unmarshal(data, &offer); err != nil {
+			return nil, err
+		}
 		if len(offer.Itineraries) == 0 || len(offer.Itineraries[0].Segments) == 0 {
 			continue
 		}
@@ -296,18 +967,65 @@ func parseAmadeusOffers(body []byte) ([]map[string]interface{}, error) {
 		departTime := timeFromISO(first.Departure.At)
 		arriveTime := timeFromISO(last.Arrival.At)
 
-		results = append(results, map[string]interface{}{
-			"airline":       first.CarrierCode,
-			"flight_number": flightNumber,
-			"origin":        first.Departure.IataCode,
-			"destination":   last.Arrival.IataCode,
-			"depart_time":   departTime,
-			"arrive_time":   arriveTime,
-			"duration":      offer.Itineraries[0].Duration,
-			"stops":         len(segments) - 1,
-			"price":         offer.Price.Total,
-			"currency":      offer.Price.Currency,
-		})
+		cabins := map[string]string{}
+		bags := map[string]int{}
+		if len(offer.TravelerPricings) > 0 {
+			for _, detail := range offer.TravelerPricings[0].FareDetailsBySegment {
+				cabins[detail.SegmentID] = normalizeCabin(detail.Cabin)
+				// A weight allowance without a piece count still means one bag.
+				bags[detail.SegmentID] = detail.IncludedCheckedBags.Quantity
+				if bags[detail.SegmentID] == 0 && detail.IncludedCheckedBags.Weight > 0 {
+					bags[detail.SegmentID] = 1
+				}
+			}
+		}
+
+		parsedSegments := convertSegments(segments, cabins, bags, dicts.Aircraft)
+		var returnSegments []Segment
+		if len(offer.Itineraries) > 1 {
+			returnSegments = convertSegments(offer.Itineraries[1].Segments, cabins, bags, dicts.Aircraft)
+		}
+
+		var identity []string
+		for _, itinerary := range offer.Itineraries {
+			for _, segment := range itinerary.Segments {
+				identity = append(identity, segment.CarrierCode+segment.Number+"@"+segment.Departure.IataCode+segment.Departure.At)
+			}
+		}
+
+		parsed := FlightOffer{
+			OfferID:        stableOfferID(identity),
+			AmadeusOfferID: offer.ID,
+			Airline:        first.CarrierCode,
+			AirlineName:    dicts.Carriers[first.CarrierCode],
+			FlightNumber:   flightNumber,
+			Origin:         first.Departure.IataCode,
+			Destination:    last.Arrival.IataCode,
+			DepartDate:     dateFromISO(first.Departure.At),
+			DepartTime:     departTime,
+			ArriveTime:     arriveTime,
+			Duration:       offer.Itineraries[0].Duration,
+			Stops:          len(segments) - 1,
+			Price:          offer.Price.Total,
+			Currency:       offer.Price.Currency,
+			Segments:       parsedSegments,
+			ReturnSegments: returnSegments,
+
+			LastTicketingDate: offer.LastTicketingDate,
+			CO2Kg:             totalEmissions(parsedSegments),
+			Layovers:          computeLayovers(parsedSegments),
+			Cabin:             predominantCabin(parsedSegments),
+		}
+		parsed.LayoverRatio = layoverRatio(parsed)
+		parsed.raw = data
+		parsed.CO2RoundTripKg = roundTripEmissions(parsed)
+		if airport, ok := overnightConnection(parsed.Segments, parsed.ReturnSegments); ok {
+			parsed.RequiresOvernightStay = true
+			parsed.OvernightAirport = airport
+			parsed.OvernightCity = metroCode(airport)
+		}
+		setNormalizedTimes(&parsed, first.Departure.At, last.Arrival.At)
+		results = append(results, parsed)
 	}
 
 	return results, nil
@@ -324,6 +1042,13 @@ func timeFromISO(value string) string {
 	return value
 }
 
+func dateFromISO(value string) string {
+	if idx := strings.Index(value, "T"); idx >= 0 {
+		return value[:idx]
+	}
+	return value
+}
+
 func getString(args map[string]interface{}, key string) string {
 	if val, ok := args[key]; ok {
 		switch v := val.(type) {
@@ -336,6 +1061,41 @@ func getString(args map[string]interface{}, key string) string {
 	return ""
 }
 
+func getBool(args map[string]interface{}, key string) bool {
+	if val, ok := args[key]; ok {
+		switch v := val.(type) {
+		case bool:
+			return v
+		case string:
+			b, _ := strconv.ParseBool(strings.TrimSpace(v))
+			return b
+		}
+	}
+	return false
+}
+
+func getStringList(args map[string]interface{}, key string) []string {
+	var values []string
+	switch v := args[key].(type) {
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+	case string:
+		values = strings.Split(v, ",")
+	}
+
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
 func getNumber(args map[string]interface{}, key string) float64 {
 	if val, ok := args[key]; ok {
 		switch v := val.(type) {
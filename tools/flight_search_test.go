@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleOffersBody is a one-offer Amadeus flight offers response.
+var sampleOffersBody = offersBody(nonstopOffer("1", "AA1", "100.00", "10:00", "11:00"))
+
+// nonstopOffer renders a JFK-BOS Amadeus offer on 2026-01-01.
+func nonstopOffer(id, flight, total, departs, arrives string) string {
+	return `{"id":"` + id + `","price":{"total":"` + total + `","currency":"USD"},"itineraries":[{"duration":"PT1H","segments":[` +
+		`{"id":"1","carrierCode":"` + flight[:2] + `","number":"` + flight[2:] + `",` +
+		`"departure":{"iataCode":"JFK","at":"2026-01-01T` + departs + `:00"},"arrival":{"iataCode":"BOS","at":"2026-01-01T` + arrives + `:00"}}]}]}`
+}
+
+func offersBody(offers ...string) string {
+	return `{"data":[` + strings.Join(offers, ",") + `]}`
+}
+
+// fakeAmadeus serves the token endpoint with token and hands every other
+// request to api. It points the tool at the server and clears the cached
+// token before and after the test.
+func fakeAmadeus(t *testing.T, token string, api http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth2/token") {
+			w.Write([]byte(`{"access_token":"` + token + `","expires_in":1799}`))
+			return
+		}
+		api(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("AMADEUS_MOCK", "")
+	t.Setenv("AMADEUS_CLIENT_ID", "client")
+	t.Setenv("AMADEUS_CLIENT_SECRET", "secret")
+	t.Setenv("AMADEUS_BASE_URL", server.URL)
+	t.Setenv("AMADEUS_ENV", "")
+	resetToken()
+	t.Cleanup(resetToken)
+	return server
+}
+
+func resetToken() {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	accessToken, tokenExpiresAt = "", time.Time{}
+}
+
+// executeTool runs the flight_search tool and decodes its JSON payload.
+func executeTool(t *testing.T, args map[string]interface{}) (map[string]interface{}, error) {
+	t.Helper()
+	result, err := (&flightSearchTool{}).Execute(context.Background(), args)
+	var payload map[string]interface{}
+	if decodeErr := json.Unmarshal([]byte(result.Content.(string)), &payload); decodeErr != nil {
+		t.Fatalf("payload %v: %v", result.Content, decodeErr)
+	}
+	return payload, err
+}
+
+func TestConfidencePrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		run  searchRun
+		want string
+	}{
+		{"mock wins over everything", searchRun{source: "mock", sandbox: true, relaxed: []string{"max_price"}, cached: true}, "mock"},
+		{"sandbox wins over relaxed and cached", searchRun{source: "amadeus", sandbox: true, relaxed: []string{"max_price"}, cached: true}, "sandbox"},
+		{"relaxed wins over cached", searchRun{source: "amadeus", relaxed: []string{"max_price"}, cached: true}, "relaxed"},
+		{"cached", searchRun{source: "amadeus", cached: true}, "cached"},
+		{"live exact", searchRun{source: "amadeus"}, "live_exact"},
+	}
+	for _, tt := range tests {
+		if got := tt.run.confidence(); got != tt.want {
+			t.Errorf("%s: confidence = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPayloadCarriesSchemaVersion(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	payload, err := executeTool(t, map[string]interface{}{"origin": "SFO", "destination": "JFK", "depart_date": "2026-03-15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload["schema_version"] != SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", payload["schema_version"], SchemaVersion)
+	}
+
+	payload, err = executeTool(t, map[string]interface{}{"origin": "SFO", "destination": "SFO", "depart_date": "2026-03-15"})
+	if err == nil {
+		t.Fatal("same origin and destination: want an error")
+	}
+	if payload["schema_version"] != SchemaVersion {
+		t.Errorf("error payload schema_version = %v, want %s", payload["schema_version"], SchemaVersion)
+	}
+}
+
+func TestGzipOffersResponse(t *testing.T) {
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write([]byte(sampleOffersBody))
+		gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	})
+
+	outcome, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) != 1 || outcome.Offers[0].FlightNumber != "AA1" {
+		t.Errorf("offers = %+v, want AA1", outcome.Offers)
+	}
+}
+
+func TestTokenStatusBeforeAndAfterFetch(t *testing.T) {
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleOffersBody))
+	})
+
+	if valid, expiresAt := TokenStatus(); valid || !expiresAt.IsZero() {
+		t.Errorf("before fetch: TokenStatus() = %v, %v, want false and zero time", valid, expiresAt)
+	}
+
+	before := time.Now()
+	if _, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01"}); err != nil {
+		t.Fatal(err)
+	}
+	valid, expiresAt := TokenStatus()
+	if !valid {
+		t.Error("after fetch: token not reported valid")
+	}
+	if want := before.Add(1799 * time.Second); expiresAt.Before(want) || expiresAt.After(time.Now().Add(1799*time.Second)) {
+		t.Errorf("after fetch: expiresAt = %v, want about %v", expiresAt, want)
+	}
+}
+
+func TestOversizedResponsesTripTheGuard(t *testing.T) {
+	params := SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01"}
+
+	t.Run("token", func(t *testing.T) {
+		fakeAmadeus(t, strings.Repeat("t", 200), func(w http.ResponseWriter, r *http.Request) {
+			t.Error("offers requested without a token")
+		})
+		t.Setenv("FLIGHT_MAX_RESPONSE_BYTES", "128")
+
+		_, err := searchFlights(context.Background(), params)
+		if err == nil || !strings.Contains(err.Error(), "exceeds 128 bytes") {
+			t.Errorf("err = %v, want the body size guard", err)
+		}
+	})
+
+	t.Run("offers", func(t *testing.T) {
+		fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sampleOffersBody))
+		})
+		t.Setenv("FLIGHT_MAX_RESPONSE_BYTES", "128")
+
+		_, err := searchFlights(context.Background(), params)
+		if err == nil || !strings.Contains(err.Error(), "exceeds 128 bytes") {
+			t.Errorf("err = %v, want the body size guard", err)
+		}
+	})
+}
+
+func TestIncludedCheckedBagsOnlyIsSent(t *testing.T) {
+	var queries []string
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("includedCheckedBagsOnly"))
+		w.Write([]byte(sampleOffersBody))
+	})
+	params := SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01"}
+
+	if _, err := searchFlights(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+	params.IncludedBagsOnly = true
+	if _, err := searchFlights(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "true" {
+		t.Errorf("includedCheckedBagsOnly sent as %q, want omitted then true", queries)
+	}
+}
+
+func TestPersistentUnauthorizedRetriesAreCapped(t *testing.T) {
+	for _, tt := range []struct {
+		retries string
+		want    int
+	}{{"", 1}, {"3", 3}, {"0", 0}} {
+		var offerCalls int
+		fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+			offerCalls++
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		t.Setenv("AMADEUS_MAX_AUTH_RETRIES", tt.retries)
+
+		_, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01"})
+		var upstream *UpstreamError
+		if !errors.As(err, &upstream) || upstream.StatusCode != http.StatusUnauthorized {
+			t.Errorf("AMADEUS_MAX_AUTH_RETRIES=%q: err = %v, want the 401", tt.retries, err)
+		}
+		if retries := offerCalls - 1; retries != tt.want {
+			t.Errorf("AMADEUS_MAX_AUTH_RETRIES=%q: retried %d times, want %d", tt.retries, retries, tt.want)
+		}
+	}
+}
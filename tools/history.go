@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPriceAlertPercent = 5
+
+type priceRecord struct {
+	Min          float64
+	Currency     string
+	Observations int
+	UpdatedAt    time.Time
+}
+
+// priceStore keeps the lowest price ever returned per query key for the
+// life of the process, bounded like the result cache by evicting the least
+// recently updated query.
+type priceStore struct {
+	mu      sync.Mutex
+	records map[string]priceRecord
+}
+
+var priceHistory = &priceStore{records: map[string]priceRecord{}}
+
+func (s *priceStore) get(key string) (priceRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[key]
+	return record, ok
+}
+
+// observe folds the cheapest offer of a search into the history. A
+// currency change restarts the record, since prices are not comparable.
+func (s *priceStore) observe(key string, offers []FlightOffer) {
+	min, currency, ok := cheapestOffer(offers)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[key]
+	if !exists && len(s.records) >= maxCacheEntries {
+		s.evictOldest()
+	}
+	if !exists || !strings.EqualFold(record.Currency, currency) || min < record.Min {
+		record.Min = min
+		record.Currency = currency
+	}
+	if !exists {
+		record.Observations = 0
+	}
+	record.Observations++
+	record.UpdatedAt = time.Now()
+	s.records[key] = record
+}
+
+func (s *priceStore) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, record := range s.records {
+		if oldestKey == "" || record.UpdatedAt.Before(oldest) {
+			oldestKey, oldest = key, record.UpdatedAt
+		}
+	}
+	delete(s.records, oldestKey)
+}
+
+func cheapestOffer(offers []FlightOffer) (float64, string, bool) {
+	var min float64
+	var currency string
+	found := false
+	for _, offer := range offers {
+		price, ok := parsePrice(offer.Price)
+		if !ok {
+			continue
+		}
+		if !found || price < min {
+			min, currency, found = price, offer.Currency, true
+		}
+	}
+	return min, currency, found
+}
+
+// flagDeals marks offers priced at or below the historical minimum, plus
+// FLIGHT_DEAL_TOLERANCE (a fraction, e.g. 0.02 for 2%).
+func flagDeals(offers []FlightOffer, record priceRecord) {
+	threshold := record.Min * (1 + dealTolerance())
+	for i := range offers {
+		if !strings.EqualFold(offers[i].Currency, record.Currency) {
+			continue
+		}
+		if price, ok := parsePrice(offers[i].Price); ok && price <= threshold {
+			offers[i].Deal = true
+		}
+	}
+}
+
+// priceDropped reports whether min undercuts the record by at least
+// FLIGHT_PRICE_ALERT_PCT percent. Different currencies never compare.
+func priceDropped(record priceRecord, min float64, currency string) bool {
+	if !strings.EqualFold(record.Currency, currency) || record.Min <= 0 {
+		return false
+	}
+	return min <= record.Min*(1-priceAlertPercent()/100)
+}
+
+func priceAlertPercent() float64 {
+	percent, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("FLIGHT_PRICE_ALERT_PCT")), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return defaultPriceAlertPercent
+	}
+	return percent
+}
+
+func dealTolerance() float64 {
+	tolerance, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("FLIGHT_DEAL_TOLERANCE")), 64)
+	if err != nil || tolerance < 0 {
+		return 0
+	}
+	return tolerance
+}
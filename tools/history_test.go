@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// freshHistory swaps in an empty price history for the duration of a test.
+func freshHistory(t *testing.T) {
+	t.Helper()
+	saved := priceHistory
+	priceHistory = &priceStore{records: map[string]priceRecord{}}
+	t.Cleanup(func() { priceHistory = saved })
+}
+
+func TestHistoryIgnoresPostSearchFilters(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	freshHistory(t)
+
+	base := SearchParams{Origin: "SFO", Destination: "JFK", DepartDate: "2026-03-15"}
+	restricted := base
+	restricted.FlightNumbers = []string{"MX880"}
+
+	if _, err := searchFlights(context.Background(), restricted); err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := searchFlights(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cheapest, _ := parsePrice(outcome.Offers[0].Price)
+	historical := outcome.Meta["historical_min"].(map[string]interface{})
+	if historical["price"] != cheapest {
+		t.Errorf("historical_min = %v, want the unfiltered minimum %v", historical["price"], cheapest)
+	}
+	if !outcome.Offers[0].Deal {
+		t.Error("cheapest offer not flagged as a deal against its own history")
+	}
+}
+
+func TestPriceHistoryIsBounded(t *testing.T) {
+	freshHistory(t)
+	offers := []FlightOffer{{Price: "100", Currency: "USD"}}
+	for i := 0; i <= maxCacheEntries; i++ {
+		priceHistory.observe(fmt.Sprintf("query-%d", i), offers)
+	}
+	if n := len(priceHistory.records); n != maxCacheEntries {
+		t.Errorf("history holds %d queries, want %d", n, maxCacheEntries)
+	}
+}
+
+func TestCacheHitsAreNotObservedAgain(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_CACHE_TTL", "10m")
+	freshHistory(t)
+	freshCache(t)
+	params := SearchParams{Origin: "SFO", Destination: "JFK", DepartDate: "2026-03-15"}
+
+	var outcome *searchOutcome
+	for i := 0; i < 3; i++ {
+		var err error
+		if outcome, err = searchFlights(context.Background(), params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if outcome.Meta["cached"] != true {
+		t.Fatal("repeat search was not served from the cache")
+	}
+	historical := outcome.Meta["historical_min"].(map[string]interface{})
+	if historical["observations"] != 1 {
+		t.Errorf("observations = %v after one fetch and two cache hits, want 1", historical["observations"])
+	}
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// PostSearchHook can rewrite the result set after filtering, ranking and
+// enrichment but before the payload is built. Returning an error fails the
+// tool call.
+type PostSearchHook func(ctx context.Context, offers []FlightOffer) ([]FlightOffer, error)
+
+var (
+	postSearchHook PostSearchHook
+	hooksMu        sync.RWMutex
+)
+
+// RegisterPostSearchHook installs hook, replacing any previous one. Pass
+// nil to remove it.
+func RegisterPostSearchHook(hook PostSearchHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	postSearchHook = hook
+}
+
+// PriceAlertHook is told when a search's cheapest offer undercuts the
+// stored historical minimum for query by at least FLIGHT_PRICE_ALERT_PCT.
+type PriceAlertHook func(query string, oldMin, newMin float64)
+
+var priceAlertHook PriceAlertHook
+
+// RegisterPriceAlertHook installs hook, replacing any previous one. Pass
+// nil to remove it.
+func RegisterPriceAlertHook(hook PriceAlertHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	priceAlertHook = hook
+}
+
+func runPriceAlertHook(query string, previous priceRecord, offers []FlightOffer) {
+	hooksMu.RLock()
+	hook := priceAlertHook
+	hooksMu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+	newMin, currency, ok := cheapestOffer(offers)
+	if ok && priceDropped(previous, newMin, currency) {
+		hook(query, previous.Min, newMin)
+	}
+}
+
+func runPostSearchHook(ctx context.Context, offers []FlightOffer) ([]FlightOffer, error) {
+	hooksMu.RLock()
+	hook := postSearchHook
+	hooksMu.RUnlock()
+
+	if hook == nil {
+		return offers, nil
+	}
+	return hook(ctx, offers)
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPostSearchHookAnnotatesOffers(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	RegisterPostSearchHook(func(ctx context.Context, offers []FlightOffer) ([]FlightOffer, error) {
+		for i := range offers {
+			offers[i].Extra = map[string]interface{}{"corporate_rate": offers[i].Airline == "MK"}
+		}
+		return offers, nil
+	})
+	t.Cleanup(func() { RegisterPostSearchHook(nil) })
+
+	outcome, err := searchFlights(context.Background(), SearchParams{Origin: "SFO", Destination: "JFK", DepartDate: "2026-03-15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) == 0 {
+		t.Fatal("no offers")
+	}
+	for _, offer := range outcome.Offers {
+		if want := offer.Airline == "MK"; offer.Extra["corporate_rate"] != want {
+			t.Errorf("offer %s extra = %v, want corporate_rate %v", offer.Ref, offer.Extra, want)
+		}
+	}
+}
+
+func TestPostSearchHookErrorFailsCall(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	hookErr := errors.New("rate service down")
+	RegisterPostSearchHook(func(ctx context.Context, offers []FlightOffer) ([]FlightOffer, error) {
+		return nil, hookErr
+	})
+	t.Cleanup(func() { RegisterPostSearchHook(nil) })
+
+	payload, err := executeTool(t, map[string]interface{}{"origin": "SFO", "destination": "JFK", "depart_date": "2026-03-15"})
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("err = %v, want the hook error", err)
+	}
+	if payload["results"] != nil {
+		t.Errorf("failed call returned results: %v", payload["results"])
+	}
+}
+
+func TestPriceAlertHookFiresOnBigDropOnly(t *testing.T) {
+	t.Setenv("FLIGHT_PRICE_ALERT_PCT", "5")
+	type alert struct {
+		query          string
+		oldMin, newMin float64
+	}
+	var alerts []alert
+	RegisterPriceAlertHook(func(query string, oldMin, newMin float64) {
+		alerts = append(alerts, alert{query, oldMin, newMin})
+	})
+	t.Cleanup(func() { RegisterPriceAlertHook(nil) })
+	previous := priceRecord{Min: 100, Currency: "USD"}
+
+	runPriceAlertHook("q", previous, []FlightOffer{{Price: "97.00", Currency: "USD"}})
+	if len(alerts) != 0 {
+		t.Fatalf("3%% drop fired %v", alerts)
+	}
+	runPriceAlertHook("q", previous, []FlightOffer{{Price: "90.00", Currency: "EUR"}})
+	if len(alerts) != 0 {
+		t.Fatalf("drop in another currency fired %v", alerts)
+	}
+	runPriceAlertHook("q", previous, []FlightOffer{{Price: "120.00", Currency: "USD"}, {Price: "90.00", Currency: "USD"}})
+	if len(alerts) != 1 || alerts[0] != (alert{"q", 100, 90}) {
+		t.Errorf("10%% drop: alerts = %v, want one from 100 to 90", alerts)
+	}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+)
+
+type LayoverExperience struct {
+	Score float64 `json:"score"`
+	Label string  `json:"label"`
+}
+
+// layoverRatings reads FLIGHT_LAYOVER_AIRPORTS, a JSON object of airport
+// code → rating from 1 (poor) to 5 (excellent). There is no built-in map;
+// without it layover_experience is not computed.
+func layoverRatings() map[string]float64 {
+	value := os.Getenv("FLIGHT_LAYOVER_AIRPORTS")
+	if value == "" {
+		return nil
+	}
+	var ratings map[string]float64
+	if err := json.Unmarshal([]byte(value), &ratings); err != nil {
+		logf("ignoring malformed FLIGHT_LAYOVER_AIRPORTS: %v", err)
+		return nil
+	}
+
+	normalized := make(map[string]float64, len(ratings))
+	for code, rating := range ratings {
+		normalized[strings.ToUpper(code)] = math.Max(1, math.Min(5, rating))
+	}
+	return normalized
+}
+
+// layoverExperience averages a 0-1 score over the rated connections of an
+// offer: the airport rating scaled by how comfortable the layover length
+// is. Offers without rated connections get no hint.
+func layoverExperience(offer FlightOffer, ratings map[string]float64) *LayoverExperience {
+	total, rated := 0.0, 0
+	for _, layover := range offer.Layovers {
+		rating, ok := ratings[layover.Airport]
+		if !ok {
+			continue
+		}
+		total += rating / 5 * layoverLengthFactor(layover.Minutes)
+		rated++
+	}
+	if rated == 0 {
+		return nil
+	}
+
+	score := math.Round(total/float64(rated)*100) / 100
+	label := "poor"
+	switch {
+	case score >= 0.75:
+		label = "good"
+	case score >= 0.5:
+		label = "fair"
+	}
+	return &LayoverExperience{Score: score, Label: label}
+}
+
+func layoverLengthFactor(minutes int) float64 {
+	switch {
+	case minutes < 45:
+		return 0.5
+	case minutes <= 180:
+		return 1
+	case minutes <= 360:
+		return 0.8
+	default:
+		return 0.6
+	}
+}
@@ -0,0 +1,23 @@
+package tools
+
+import "testing"
+
+func TestLayoverExperienceForRatedAirport(t *testing.T) {
+	t.Setenv("FLIGHT_LAYOVER_AIRPORTS", `{"sin":5,"ord":2}`)
+	offers := []FlightOffer{
+		{OfferID: "sin", Layovers: []Layover{{Airport: "SIN", Minutes: 120}}},
+		{OfferID: "ord-long", Layovers: []Layover{{Airport: "ORD", Minutes: 300}}},
+		{OfferID: "unrated", Layovers: []Layover{{Airport: "DEN", Minutes: 90}}},
+	}
+
+	enrichOffers(offers, SearchParams{})
+	if got := offers[0].LayoverExperience; got == nil || *got != (LayoverExperience{Score: 1, Label: "good"}) {
+		t.Errorf("two hours at SIN: layover_experience = %v, want 1 good", got)
+	}
+	if got := offers[1].LayoverExperience; got == nil || *got != (LayoverExperience{Score: 0.32, Label: "poor"}) {
+		t.Errorf("five hours at ORD: layover_experience = %v, want 0.32 poor", got)
+	}
+	if got := offers[2].LayoverExperience; got != nil {
+		t.Errorf("unrated airport: layover_experience = %v, want none", got)
+	}
+}
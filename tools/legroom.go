@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+type LegroomHint struct {
+	Aircraft    string  `json:"aircraft"`
+	SeatPitchIn float64 `json:"seat_pitch_in"`
+	Label       string  `json:"label"`
+}
+
+// seatPitches reads FLIGHT_SEAT_PITCH, a JSON object of Amadeus aircraft
+// code → typical economy seat pitch in inches. There is no built-in map;
+// without it legroom_hint is not computed.
+func seatPitches() map[string]float64 {
+	value := os.Getenv("FLIGHT_SEAT_PITCH")
+	if value == "" {
+		return nil
+	}
+	var pitches map[string]float64
+	if err := json.Unmarshal([]byte(value), &pitches); err != nil {
+		logf("ignoring malformed FLIGHT_SEAT_PITCH: %v", err)
+		return nil
+	}
+
+	normalized := make(map[string]float64, len(pitches))
+	for code, pitch := range pitches {
+		if pitch > 0 {
+			normalized[strings.ToUpper(strings.TrimSpace(code))] = pitch
+		}
+	}
+	return normalized
+}
+
+// legroomHint reports the tightest mapped aircraft across both legs.
+// Segments without aircraft data or missing from the map are ignored; an
+// offer with no mapped segment gets no hint.
+func legroomHint(offer FlightOffer, pitches map[string]float64) *LegroomHint {
+	var hint *LegroomHint
+	for _, segments := range [][]Segment{offer.Segments, offer.ReturnSegments} {
+		for _, segment := range segments {
+			code := strings.ToUpper(segment.Aircraft)
+			pitch, ok := pitches[code]
+			if !ok || (hint != nil && pitch >= hint.SeatPitchIn) {
+				continue
+			}
+			hint = &LegroomHint{Aircraft: code, SeatPitchIn: pitch, Label: legroomLabel(pitch)}
+		}
+	}
+	return hint
+}
+
+func legroomLabel(pitch float64) string {
+	switch {
+	case pitch < 30:
+		return "tight"
+	case pitch < 32:
+		return "standard"
+	default:
+		return "roomy"
+	}
+}
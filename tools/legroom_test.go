@@ -0,0 +1,19 @@
+package tools
+
+import "testing"
+
+func TestLegroomHintForMappedAircraft(t *testing.T) {
+	t.Setenv("FLIGHT_SEAT_PITCH", `{"32n":29,"789":32}`)
+	offers := []FlightOffer{
+		{OfferID: "mapped", Segments: []Segment{{Aircraft: "789"}, {Aircraft: "32N"}, {Aircraft: "E75"}}},
+		{OfferID: "unmapped", Segments: []Segment{{Aircraft: "E75"}}},
+	}
+
+	enrichOffers(offers, SearchParams{})
+	if got := offers[0].LegroomHint; got == nil || *got != (LegroomHint{Aircraft: "32N", SeatPitchIn: 29, Label: "tight"}) {
+		t.Errorf("legroom_hint = %+v, want the 29in 32N marked tight", got)
+	}
+	if got := offers[1].LegroomHint; got != nil {
+		t.Errorf("unmapped aircraft: legroom_hint = %+v, want none", got)
+	}
+}
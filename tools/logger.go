@@ -0,0 +1,13 @@
+package tools
+
+import "log"
+
+// Logger receives non-fatal warnings from the tool. Replace it to route
+// warnings elsewhere, or set it to nil to silence them.
+var Logger func(format string, args ...interface{}) = log.Printf
+
+func logf(format string, args ...interface{}) {
+	if Logger != nil {
+		Logger("flight_search: "+format, args...)
+	}
+}
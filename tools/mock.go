@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type mockFetcher struct{}
+
+type mockLeg struct {
+	carrier string
+	number  string
+	via     string
+	depart  string
+	arrive  string
+	layover string
+	arrive2 string
+	dur     string
+	bags    int
+}
+
+var mockLegs = []mockLeg{
+	{carrier: "MK", number: "101", depart: "07:10", arrive: "12:40", dur: "PT5H30M", bags: 1},
+	{carrier: "MK", number: "215", via: "ORD", depart: "13:05", arrive: "15:10", layover: "16:20", arrive2: "20:45", dur: "PT7H40M", bags: 1},
+	{carrier: "MX", number: "880", depart: "19:30", arrive: "22:55", dur: "PT3H25M"},
+}
+
+func mockEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("AMADEUS_MOCK"))
+	return enabled
+}
+
+// fetchOffers builds an Amadeus-shaped response so mock results go through
+// the same parser as live ones.
+func (mockFetcher) fetchOffers(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(params.Currency)
+	if currency == "" {
+		currency = "USD"
+	}
+	cabin := normalizeCabin(params.Cabin)
+	if cabin == "" {
+		cabin = "ECONOMY"
+	}
+	base := mockBasePrice(req.Origin, req.Destination, req.DepartDate)
+
+	data := make([]map[string]interface{}, 0, len(mockLegs))
+	for i, leg := range mockLegs {
+		price := base + float64(i*45)
+		if params.MaxPrice > 0 && price > params.MaxPrice {
+			continue
+		}
+		if params.NonStop && leg.via != "" {
+			continue
+		}
+		if params.IncludedBagsOnly && leg.bags == 0 {
+			continue
+		}
+
+		var segments []map[string]interface{}
+		if leg.via == "" {
+			segments = append(segments, mockSegment(leg.carrier, leg.number, req.Origin, req.Destination, req.DepartDate, leg.depart, leg.arrive))
+		} else {
+			segments = append(segments,
+				mockSegment(leg.carrier, leg.number, req.Origin, leg.via, req.DepartDate, leg.depart, leg.arrive),
+				mockSegment(leg.carrier, leg.number+"1", leg.via, req.Destination, req.DepartDate, leg.layover, leg.arrive2),
+			)
+		}
+
+		fareDetails := make([]map[string]interface{}, 0, len(segments))
+		for j, segment := range segments {
+			segment["id"] = strconv.Itoa(j + 1)
+			fareDetails = append(fareDetails, map[string]interface{}{
+				"segmentId":           segment["id"],
+				"cabin":               cabin,
+				"includedCheckedBags": map[string]interface{}{"quantity": leg.bags},
+			})
+		}
+
+		data = append(data, map[string]interface{}{
+			"id": strconv.Itoa(i + 1),
+			"price": map[string]interface{}{
+				"total":    fmt.Sprintf("%.2f", price),
+				"currency": currency,
+			},
+			"itineraries": []map[string]interface{}{
+				{"duration": leg.dur, "segments": segments},
+			},
+			"travelerPricings": []map[string]interface{}{
+				{"fareDetailsBySegment": fareDetails},
+			},
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return nil, err
+	}
+	return parseAmadeusOffers(body)
+}
+
+func mockSegment(carrier, number, from, to, date, depart, arrive string) map[string]interface{} {
+	return map[string]interface{}{
+		"carrierCode": carrier,
+		"number":      number,
+		"departure":   map[string]interface{}{"iataCode": strings.ToUpper(from), "at": date + "T" + depart + ":00"},
+		"arrival":     map[string]interface{}{"iataCode": strings.ToUpper(to), "at": date + "T" + arrive + ":00"},
+	}
+}
+
+func mockBasePrice(origin, destination, date string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(origin + destination + date)))
+	return 180 + float64(h.Sum32()%220)
+}
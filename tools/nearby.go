@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultNearbyProbes     = 3
+	defaultNearbyMinSavings = 0.1
+)
+
+// suggestNearby probes nearby-airport routes on the requested dates only
+// and reports the cheapest one when it undercuts best by at least
+// FLIGHT_NEARBY_MIN_SAVINGS. Probes count against the call's request
+// budget. The probe never fails the search: errors are logged and yield no
+// suggestion.
+func (r *searchRun) suggestNearby(ctx context.Context, params SearchParams, best []FlightOffer) map[string]interface{} {
+	baseMin, currency, ok := cheapestOffer(best)
+	if !ok {
+		return nil
+	}
+
+	probe := params
+	probe.IncludeNearby = true
+	routes := planRoutes(probe)[1:]
+	if limit := nearbyProbes(); len(routes) > limit {
+		routes = routes[:limit]
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	if r.budget.exhausted() {
+		r.budget.skip("suggest_nearby")
+		return nil
+	}
+	requests := make([]searchRequest, 0, len(routes))
+	for _, rt := range routes {
+		requests = append(requests, searchRequest{
+			Origin:      rt.origin,
+			Destination: rt.destination,
+			DepartDate:  params.DepartDate,
+			ReturnDate:  params.ReturnDate,
+		})
+	}
+
+	requests = r.budget.take(requests)
+	r.budget.spend(len(requests))
+	offers, err := runRequests(ctx, r.fetcher, r.sem, params, requests)
+	if err != nil {
+		logf("nearby probe failed: %v", err)
+		return nil
+	}
+	offers = applyFilters(offers, params)
+
+	var cheapest *FlightOffer
+	var cheapestPrice float64
+	for i := range offers {
+		price, ok := parsePrice(offers[i].Price)
+		if !ok || !strings.EqualFold(offers[i].Currency, currency) {
+			continue
+		}
+		if cheapest == nil || price < cheapestPrice {
+			cheapest, cheapestPrice = &offers[i], price
+		}
+	}
+	if cheapest == nil || cheapestPrice > baseMin*(1-nearbyMinSavings()) {
+		return nil
+	}
+
+	airports := []string{}
+	if !strings.EqualFold(cheapest.Origin, params.Origin) {
+		airports = append(airports, cheapest.Origin)
+	}
+	if !strings.EqualFold(cheapest.Destination, params.Destination) {
+		airports = append(airports, cheapest.Destination)
+	}
+	return map[string]interface{}{
+		"airports":    airports,
+		"origin":      cheapest.Origin,
+		"destination": cheapest.Destination,
+		"price":       cheapestPrice,
+		"currency":    currency,
+		"savings":     math.Round((baseMin-cheapestPrice)*100) / 100,
+		"offer_id":    cheapest.OfferID,
+	}
+}
+
+// nearbyProbes reads FLIGHT_NEARBY_PROBES, the most nearby routes probed;
+// 0 disables the probe.
+func nearbyProbes() int {
+	if n := envInt("FLIGHT_NEARBY_PROBES", defaultNearbyProbes); n >= 0 {
+		return n
+	}
+	return defaultNearbyProbes
+}
+
+// nearbyMinSavings reads FLIGHT_NEARBY_MIN_SAVINGS as a fraction of the
+// primary cheapest fare (e.g. 0.1 for 10%).
+func nearbyMinSavings() float64 {
+	savings, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("FLIGHT_NEARBY_MIN_SAVINGS")), 64)
+	if err != nil || savings < 0 || savings >= 1 {
+		return defaultNearbyMinSavings
+	}
+	return savings
+}
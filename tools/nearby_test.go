@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// routePrices serves one offer per route, priced from the map.
+func routePrices(prices map[string]string, calls *int32) offerFetcher {
+	return fetcherFunc(func(ctx context.Context, params SearchParams, req searchRequest) ([]FlightOffer, error) {
+		atomic.AddInt32(calls, 1)
+		price, ok := prices[req.Origin+"-"+req.Destination]
+		if !ok {
+			return nil, nil
+		}
+		return []FlightOffer{{
+			OfferID:     req.Origin + "-" + req.Destination,
+			Origin:      req.Origin,
+			Destination: req.Destination,
+			Price:       price,
+			Currency:    "USD",
+		}}, nil
+	})
+}
+
+func TestSuggestNearbyFindsCheaperAirport(t *testing.T) {
+	var calls int32
+	prices := map[string]string{"JFK-LAX": "300", "EWR-LAX": "200"}
+	run := &searchRun{fetcher: routePrices(prices, &calls), sem: newLimiter(), budget: &requestBudget{}}
+	params := SearchParams{Origin: "JFK", Destination: "LAX", DepartDate: "2026-12-01"}
+	primary := []FlightOffer{{Price: "300", Currency: "USD"}}
+
+	suggestion := run.suggestNearby(context.Background(), params, primary)
+	if suggestion == nil {
+		t.Fatal("expected a nearby suggestion")
+	}
+	if suggestion["origin"] != "EWR" || suggestion["price"] != 200.0 || suggestion["savings"] != 100.0 {
+		t.Errorf("suggestion = %v", suggestion)
+	}
+
+	prices["EWR-LAX"] = "290"
+	if suggestion := run.suggestNearby(context.Background(), params, primary); suggestion != nil {
+		t.Errorf("suggestion for a 3%% saving = %v, want none", suggestion)
+	}
+}
+
+func TestSuggestNearbyRespectsRequestBudget(t *testing.T) {
+	var calls int32
+	prices := map[string]string{"EWR-LAX": "200"}
+	params := SearchParams{Origin: "JFK", Destination: "LAX", DepartDate: "2026-12-01"}
+	primary := []FlightOffer{{Price: "300", Currency: "USD"}}
+
+	run := &searchRun{fetcher: routePrices(prices, &calls), sem: newLimiter(), budget: &requestBudget{limit: 2, used: 1}}
+	run.suggestNearby(context.Background(), params, primary)
+	if calls != 1 {
+		t.Errorf("probe sent %d requests, want 1 (remaining budget)", calls)
+	}
+
+	calls = 0
+	run.budget = &requestBudget{limit: 1, used: 1}
+	if suggestion := run.suggestNearby(context.Background(), params, primary); suggestion != nil || calls != 0 {
+		t.Errorf("probe ran with no budget left: %d requests, suggestion %v", calls, suggestion)
+	}
+	if len(run.budget.skipped) != 1 || run.budget.skipped[0] != "suggest_nearby" {
+		t.Errorf("skipped = %v, want [suggest_nearby]", run.budget.skipped)
+	}
+}
+
+func TestNearbyProbesRejectsNegative(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	t.Setenv("FLIGHT_NEARBY_PROBES", "-1")
+	if got := nearbyProbes(); got != defaultNearbyProbes {
+		t.Errorf("FLIGHT_NEARBY_PROBES=-1: nearbyProbes() = %d, want the default %d", got, defaultNearbyProbes)
+	}
+
+	if _, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "LAX", DepartDate: "2026-12-01", SuggestNearby: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FLIGHT_NEARBY_PROBES", "0")
+	var calls int32
+	run := &searchRun{fetcher: routePrices(map[string]string{"EWR-LAX": "200"}, &calls), sem: newLimiter(), budget: &requestBudget{}}
+	params := SearchParams{Origin: "JFK", Destination: "LAX", DepartDate: "2026-12-01"}
+	if suggestion := run.suggestNearby(context.Background(), params, []FlightOffer{{Price: "300", Currency: "USD"}}); suggestion != nil || calls != 0 {
+		t.Errorf("FLIGHT_NEARBY_PROBES=0: %d requests, suggestion %v, want no probe", calls, suggestion)
+	}
+}
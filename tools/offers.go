@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stableOfferID identifies an itinerary by its flights and departure
+// times, so the same routing keeps its ID across searches even when the
+// price or the Amadeus offer ID changes.
+func stableOfferID(flights []string) string {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(flights, "|")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// parsePrice accepts Amadeus decimal strings as well as values with
+// thousands separators or a leading currency symbol.
+func parsePrice(value string) (float64, bool) {
+	cleaned := strings.TrimSpace(value)
+	cleaned = strings.TrimLeft(cleaned, "$€£¥ ")
+	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	if cleaned == "" {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || price < 0 {
+		return 0, false
+	}
+	return price, true
+}
+
+// assignRefs labels offers A, B, ... Z, AA, AB ... in their final order.
+// Refs are only meaningful within one response; each maps back to the
+// offer's request and amadeus_offer_id, which are unique within it too.
+func assignRefs(offers []FlightOffer) {
+	for i := range offers {
+		offers[i].Ref = offerRef(i)
+	}
+}
+
+func offerRef(index int) string {
+	ref := ""
+	for index >= 0 {
+		ref = string(rune('A'+index%26)) + ref
+		index = index/26 - 1
+	}
+	return ref
+}
+
+func addPriceRange(meta map[string]interface{}, offers []FlightOffer) {
+	var currency string
+	var min, max float64
+	seen := false
+	for _, offer := range offers {
+		price, ok := parsePrice(offer.Price)
+		if !ok {
+			continue
+		}
+		if !seen {
+			currency, min, max, seen = offer.Currency, price, price, true
+			continue
+		}
+		if !strings.EqualFold(offer.Currency, currency) {
+			meta["price_range_note"] = "offers use mixed currencies; price range omitted"
+			return
+		}
+		if price < min {
+			min = price
+		}
+		if price > max {
+			max = price
+		}
+	}
+	if !seen {
+		return
+	}
+
+	meta["price_min"] = min
+	meta["price_max"] = max
+	meta["price_currency"] = currency
+}
+
+// parseISODuration converts Amadeus durations such as "PT7H40M" or
+// "P1DT2H" to minutes.
+func parseISODuration(value string) (int, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if !strings.HasPrefix(value, "P") {
+		return 0, false
+	}
+
+	minutes := 0
+	number := ""
+	inTime := false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, false
+			}
+			number = ""
+			switch {
+			case r == 'D':
+				minutes += n * 24 * 60
+			case r == 'H' && inTime:
+				minutes += n * 60
+			case r == 'M' && inTime:
+				minutes += n
+			case r == 'S' && inTime:
+			default:
+				return 0, false
+			}
+		}
+	}
+	if number != "" {
+		return 0, false
+	}
+	return minutes, true
+}
+
+// parseLocalTime reads Amadeus segment timestamps, which are local to the
+// airport and usually carry no offset.
+func parseLocalTime(value string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", time.RFC3339} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeDateTime re-emits a timestamp as RFC3339, preserving its
+// offset. Amadeus normally returns airport-local times without an offset;
+// those cannot be RFC3339, so they come back as "2006-01-02T15:04:05" with
+// naive set.
+func normalizeDateTime(value string) (normalized string, naive bool, ok bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Format(time.RFC3339), false, true
+	}
+	if t, ok := parseLocalTime(value); ok {
+		return t.Format("2006-01-02T15:04:05"), true, true
+	}
+	return "", false, false
+}
+
+func setNormalizedTimes(offer *FlightOffer, departAt, arriveAt string) {
+	depart, departNaive, departOK := normalizeDateTime(departAt)
+	arrive, arriveNaive, arriveOK := normalizeDateTime(arriveAt)
+	if departOK {
+		offer.DepartAtRFC3339 = depart
+	}
+	if arriveOK {
+		offer.ArriveAtRFC3339 = arrive
+	}
+	offer.TimesNaive = (departOK && departNaive) || (arriveOK && arriveNaive)
+}
+
+// minuteOfDay returns the wall-clock minute (0-1439) of a local timestamp.
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// parseClockRange reads "HH:MM-HH:MM" into minutes of the day.
+func parseClockRange(value string) (start, end int, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	from, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, false
+	}
+	return minuteOfDay(from), minuteOfDay(to), true
+}
+
+// inClockRange reports whether minute falls in [start, end), wrapping past
+// midnight when end is before start.
+func inClockRange(minute, start, end int) bool {
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+type Layover struct {
+	Airport string `json:"airport"`
+	Minutes int    `json:"minutes"`
+}
+
+// computeLayovers measures the ground time between consecutive segments.
+// Both timestamps are local to the connection airport, so the difference
+// needs no time zone data.
+func computeLayovers(segments []Segment) []Layover {
+	if len(segments) < 2 {
+		return nil
+	}
+	layovers := make([]Layover, 0, len(segments)-1)
+	for i := 1; i < len(segments); i++ {
+		arrive, okArrive := parseLocalTime(segments[i-1].ArriveAt)
+		depart, okDepart := parseLocalTime(segments[i].DepartAt)
+		if !okArrive || !okDepart {
+			continue
+		}
+		layovers = append(layovers, Layover{Airport: segments[i-1].To, Minutes: int(depart.Sub(arrive).Minutes())})
+	}
+	return layovers
+}
+
+const defaultOvernightLayover = 240
+
+// overnightConnection returns the first connection, across all legs, where
+// the next flight leaves on a later local date than the previous one
+// landed after at least FLIGHT_OVERNIGHT_MIN_LAYOVER minutes on the ground.
+func overnightConnection(legs ...[]Segment) (string, bool) {
+	minMinutes := envInt("FLIGHT_OVERNIGHT_MIN_LAYOVER", defaultOvernightLayover)
+	for _, segments := range legs {
+		for i := 1; i < len(segments); i++ {
+			arrive, okArrive := parseLocalTime(segments[i-1].ArriveAt)
+			depart, okDepart := parseLocalTime(segments[i].DepartAt)
+			if !okArrive || !okDepart {
+				continue
+			}
+			if depart.Sub(arrive).Minutes() >= float64(minMinutes) && depart.Format(dateLayout) > arrive.Format(dateLayout) {
+				return segments[i-1].To, true
+			}
+		}
+	}
+	return "", false
+}
+
+// layoverRatio is total layover time over total elapsed time; nonstop
+// offers score 0. It is nil when the elapsed time cannot be parsed.
+func layoverRatio(offer FlightOffer) *float64 {
+	elapsed, ok := parseISODuration(offer.Duration)
+	if offer.Stops == 0 {
+		zero := 0.0
+		return &zero
+	}
+	if !ok || elapsed <= 0 {
+		return nil
+	}
+	total := 0
+	for _, layover := range offer.Layovers {
+		total += layover.Minutes
+	}
+	ratio := math.Round(float64(total)/float64(elapsed)*1000) / 1000
+	return &ratio
+}
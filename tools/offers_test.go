@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestOfferRefsMapToRequestAndAmadeusID(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "SFO",
+		Destination: "JFK",
+		DepartDate:  "2026-03-15",
+		FlexDays:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) != 9 {
+		t.Fatalf("got %d offers, want 9", len(outcome.Offers))
+	}
+
+	wantRefs := []string{"A", "B", "C", "D", "E", "F", "G", "H", "I"}
+	seen := map[string]string{}
+	for i, offer := range outcome.Offers {
+		if offer.Ref != wantRefs[i] {
+			t.Errorf("offer %d ref = %q, want %q", i, offer.Ref, wantRefs[i])
+		}
+
+		// The mock numbers its legs "1", "2", "3" per response and prices
+		// leg n at the request's base price plus (n-1)*45, so the price
+		// behind each ref pins down which request and id it maps to.
+		id, err := strconv.Atoi(offer.AmadeusOfferID)
+		if err != nil || id < 1 || id > 3 {
+			t.Fatalf("ref %s: amadeus_offer_id = %q, want the mock's own id 1-3", offer.Ref, offer.AmadeusOfferID)
+		}
+		if want := "SFO-JFK " + offer.DepartDate; offer.Request != want {
+			t.Errorf("ref %s: request = %q, want %q", offer.Ref, offer.Request, want)
+		}
+		wantPrice := mockBasePrice("SFO", "JFK", offer.DepartDate) + float64((id-1)*45)
+		if price, _ := parsePrice(offer.Price); price != wantPrice {
+			t.Errorf("ref %s maps to %s #%d priced %s, want %.2f", offer.Ref, offer.Request, id, offer.Price, wantPrice)
+		}
+
+		key := offer.Request + "#" + offer.AmadeusOfferID
+		if previous, ok := seen[key]; ok {
+			t.Errorf("request %q and amadeus_offer_id %q used by refs %s and %s", offer.Request, offer.AmadeusOfferID, previous, offer.Ref)
+		}
+		seen[key] = offer.Ref
+	}
+}
+
+func TestAddPriceRange(t *testing.T) {
+	meta := map[string]interface{}{}
+	addPriceRange(meta, []FlightOffer{
+		{Price: "420.50", Currency: "EUR"},
+		{Price: "n/a", Currency: "EUR"},
+		{Price: "199.00", Currency: "eur"},
+		{Price: "310.00", Currency: "EUR"},
+	})
+	if meta["price_min"] != 199.0 || meta["price_max"] != 420.5 || meta["price_currency"] != "EUR" {
+		t.Errorf("price range = %v", meta)
+	}
+
+	meta = map[string]interface{}{}
+	addPriceRange(meta, []FlightOffer{
+		{Price: "420.50", Currency: "EUR"},
+		{Price: "199.00", Currency: "USD"},
+	})
+	if _, ok := meta["price_min"]; ok {
+		t.Errorf("mixed currencies: price_min = %v, want omitted", meta["price_min"])
+	}
+	if meta["price_range_note"] == nil {
+		t.Error("mixed currencies: price_range_note missing")
+	}
+}
+
+func TestLayoverRatio(t *testing.T) {
+	body := offersBody(
+		`{"id":"1","price":{"total":"300.00","currency":"USD"},"itineraries":[{"duration":"PT10H","segments":[`+
+			`{"id":"1","carrierCode":"AA","number":"10","departure":{"iataCode":"JFK","at":"2026-01-01T08:00:00"},"arrival":{"iataCode":"ORD","at":"2026-01-01T10:00:00"}},`+
+			`{"id":"2","carrierCode":"AA","number":"20","departure":{"iataCode":"ORD","at":"2026-01-01T16:00:00"},"arrival":{"iataCode":"BOS","at":"2026-01-01T18:00:00"}}]}]}`,
+		nonstopOffer("2", "AA1", "100.00", "10:00", "11:00"),
+	)
+	offers, err := parseAmadeusOffers([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 2 {
+		t.Fatalf("got %d offers, want 2", len(offers))
+	}
+	if ratio := offers[0].LayoverRatio; ratio == nil || *ratio != 0.6 {
+		t.Errorf("six-hour layover in ten hours: layover_ratio = %v, want 0.6", ratio)
+	}
+	if ratio := offers[1].LayoverRatio; ratio == nil || *ratio != 0 {
+		t.Errorf("nonstop: layover_ratio = %v, want 0", ratio)
+	}
+}
+
+func TestNormalizedDateTimes(t *testing.T) {
+	var offset FlightOffer
+	setNormalizedTimes(&offset, "2026-11-10T22:15:00+01:00", "2026-11-11T06:40:00-05:00")
+	if offset.DepartAtRFC3339 != "2026-11-10T22:15:00+01:00" || offset.ArriveAtRFC3339 != "2026-11-11T06:40:00-05:00" {
+		t.Errorf("offset times = %q, %q, want them unchanged", offset.DepartAtRFC3339, offset.ArriveAtRFC3339)
+	}
+	if offset.TimesNaive {
+		t.Error("offset times marked times_naive")
+	}
+
+	var naive FlightOffer
+	setNormalizedTimes(&naive, "2026-11-10T22:15", "2026-11-11T06:40:00")
+	if naive.DepartAtRFC3339 != "2026-11-10T22:15:00" || naive.ArriveAtRFC3339 != "2026-11-11T06:40:00" {
+		t.Errorf("naive times = %q, %q", naive.DepartAtRFC3339, naive.ArriveAtRFC3339)
+	}
+	if !naive.TimesNaive {
+		t.Error("airport-local times without an offset not marked times_naive")
+	}
+}
+
+func TestOvernightConnectionDetectedAndFiltered(t *testing.T) {
+	body := offersBody(
+		`{"id":"1","price":{"total":"700.00","currency":"USD"},"itineraries":[{"duration":"PT38H30M","segments":[`+
+			`{"id":"1","carrierCode":"BA","number":"178","departure":{"iataCode":"JFK","at":"2026-01-01T08:00:00"},"arrival":{"iataCode":"LHR","at":"2026-01-01T20:00:00"}},`+
+			`{"id":"2","carrierCode":"BA","number":"143","departure":{"iataCode":"LHR","at":"2026-01-02T09:00:00"},"arrival":{"iataCode":"DEL","at":"2026-01-02T22:30:00"}}]}]}`,
+		`{"id":"2","price":{"total":"900.00","currency":"USD"},"itineraries":[{"duration":"PT16H","segments":[`+
+			`{"id":"1","carrierCode":"BA","number":"176","departure":{"iataCode":"JFK","at":"2026-01-01T21:00:00"},"arrival":{"iataCode":"LHR","at":"2026-01-02T09:00:00"}},`+
+			`{"id":"2","carrierCode":"BA","number":"143","departure":{"iataCode":"LHR","at":"2026-01-02T11:00:00"},"arrival":{"iataCode":"DEL","at":"2026-01-03T00:30:00"}}]}]}`,
+	)
+	offers, err := parseAmadeusOffers([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !offers[0].RequiresOvernightStay || offers[0].OvernightAirport != "LHR" || offers[0].OvernightCity != "LON" {
+		t.Errorf("night at LHR: requires_overnight_stay=%v airport=%q city=%q", offers[0].RequiresOvernightStay, offers[0].OvernightAirport, offers[0].OvernightCity)
+	}
+	if offers[1].RequiresOvernightStay {
+		t.Error("two-hour morning connection flagged as overnight")
+	}
+
+	kept, stats := filterOffers(offers, SearchParams{NoOvernightStay: true})
+	if len(kept) != 1 || kept[0].AmadeusOfferID != "2" || stats["no_overnight_stay"] != 1 {
+		t.Errorf("no_overnight_stay kept %v with stats %v, want offer 2 only", kept, stats)
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type SearchParams struct {
+	Origin      string
+	Destination string
+	DepartDate  string
+	ReturnDate  string
+	Passengers  int
+	Cabin       string
+	MaxPrice    float64
+	Budget      float64
+	Currency    string
+	FlexDays    int
+
+	PriceRounding string
+
+	NonStop          bool
+	IncludeNearby    bool
+	SuggestNearby    bool
+	MinResults       int
+	IncludedBagsOnly bool
+	BagsIncluded     bool
+
+	RelaxIfEmpty bool
+
+	MileageProgram    string
+	MileageOnly       bool
+	PreferredAlliance string
+
+	SortBy         string
+	ScoreDetail    bool
+	BestPerDaypart bool
+	Explain        bool
+	DebugCurl      bool
+
+	FlightNumbers   []string
+	DiffFromCache   bool
+	ExcludeRedeye   bool
+	NoOvernightStay bool
+
+	ExcludeShortLayovers bool
+	BusinessHoursOnly    bool
+	IncludeCoords        bool
+	RouteStyle           string
+
+	BookableWithinDays int
+
+	AllowedConnectionCountries []string
+	StrictConnectionCountries  bool
+}
+
+func parseSearchParams(args map[string]interface{}) SearchParams {
+	return SearchParams{
+		Origin:      getString(args, "origin"),
+		Destination: getString(args, "destination"),
+		DepartDate:  getString(args, "depart_date"),
+		ReturnDate:  getString(args, "return_date"),
+		Passengers:  int(getNumber(args, "passengers")),
+		Cabin:       getString(args, "cabin"),
+		MaxPrice:    getNumber(args, "max_price"),
+		Budget:      getNumber(args, "budget"),
+		Currency:    getString(args, "currency"),
+		FlexDays:    int(getNumber(args, "flex_days")),
+
+		PriceRounding: getString(args, "price_rounding"),
+
+		NonStop:       getBool(args, "nonstop"),
+		IncludeNearby: getBool(args, "include_nearby"),
+		SuggestNearby: getBool(args, "suggest_nearby"),
+		MinResults:    int(getNumber(args, "min_results")),
+
+		IncludedBagsOnly: getBool(args, "included_bags_only"),
+		BagsIncluded:     getBool(args, "bags_included"),
+
+		RelaxIfEmpty: getBool(args, "relax_if_empty"),
+
+		MileageProgram:    getString(args, "mileage_program"),
+		MileageOnly:       getBool(args, "mileage_only"),
+		PreferredAlliance: getString(args, "preferred_alliance"),
+
+		SortBy:         getString(args, "sort_by"),
+		ScoreDetail:    getBool(args, "score_detail"),
+		BestPerDaypart: getBool(args, "best_per_daypart"),
+		Explain:        getBool(args, "explain"),
+		DebugCurl:      getBool(args, "debug_curl"),
+
+		FlightNumbers:   getStringList(args, "flight_numbers"),
+		DiffFromCache:   getBool(args, "diff_from_cache"),
+		ExcludeRedeye:   getBool(args, "exclude_redeye"),
+		NoOvernightStay: getBool(args, "no_overnight_stay"),
+
+		ExcludeShortLayovers: getBool(args, "exclude_short_layovers"),
+		BusinessHoursOnly:    getBool(args, "business_hours_only"),
+		IncludeCoords:        getBool(args, "include_coords"),
+		RouteStyle:           getString(args, "route_style"),
+
+		BookableWithinDays: int(getNumber(args, "bookable_within_days")),
+
+		AllowedConnectionCountries: getStringList(args, "allowed_connection_countries"),
+		StrictConnectionCountries:  getBool(args, "strict_connection_countries"),
+	}
+}
+
+func validateSearchParams(params SearchParams) error {
+	origin := strings.ToUpper(strings.TrimSpace(params.Origin))
+	destination := strings.ToUpper(strings.TrimSpace(params.Destination))
+	if origin != "" && origin == destination {
+		return &ArgumentError{Arg: "destination", Msg: fmt.Sprintf("origin and destination are both %s", origin)}
+	}
+	if origin != "" && destination != "" && metroCode(origin) == metroCode(destination) {
+		return &ArgumentError{Arg: "destination", Msg: fmt.Sprintf("%s and %s are in the same metro area (%s)", origin, destination, metroCode(origin))}
+	}
+	if _, _, err := preferenceCarriers(params); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SearchCacheKey is the canonical key the result cache and price history
+// use for params. It covers only what shapes the Amadeus requests, with
+// codes case- and whitespace-normalized and defaults applied, so
+// equivalent searches share a key however their arguments were given.
+// Post-search options such as filters and sorting do not affect it.
+func SearchCacheKey(params SearchParams) string {
+	passengers := params.Passengers
+	if passengers <= 0 {
+		passengers = 1
+	}
+	flex := params.FlexDays
+	if flex < 0 {
+		flex = 0
+	} else if flex > maxFlexDays {
+		flex = maxFlexDays
+	}
+	maxPrice := params.MaxPrice
+	if maxPrice < 0 {
+		maxPrice = 0
+	}
+	return strings.Join([]string{
+		normalizeCode(params.Origin),
+		normalizeCode(params.Destination),
+		strings.TrimSpace(params.DepartDate),
+		strings.TrimSpace(params.ReturnDate),
+		strconv.Itoa(passengers),
+		normalizeCode(params.Cabin),
+		normalizeCode(params.Currency),
+		strconv.FormatFloat(maxPrice, 'f', 0, 64),
+		strconv.Itoa(flex),
+		strconv.FormatBool(params.NonStop),
+		strconv.FormatBool(params.IncludeNearby),
+		strconv.FormatBool(params.IncludedBagsOnly),
+	}, "|")
+}
+
+func normalizeCode(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
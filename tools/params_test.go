@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRejectsImpossibleRoutings(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+	tests := []struct {
+		origin, destination string
+		message             string
+	}{
+		{"jfk", " JFK ", "origin and destination are both JFK"},
+		{"JFK", "NYC", "same metro area (NYC)"},
+		{"EWR", "LGA", "same metro area (NYC)"},
+	}
+	for _, tt := range tests {
+		_, err := searchFlights(context.Background(), SearchParams{Origin: tt.origin, Destination: tt.destination, DepartDate: "2026-03-15"})
+		if ErrorCode(err) != ErrorCodeInvalidArgument || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s-%s: err = %v, want invalid argument mentioning %q", tt.origin, tt.destination, err, tt.message)
+		}
+	}
+
+	if err := validateSearchParams(SearchParams{Origin: "JFK", Destination: "BOS"}); err != nil {
+		t.Errorf("JFK-BOS: %v", err)
+	}
+}
+
+func TestSearchCacheKeyForEquivalentParams(t *testing.T) {
+	base := SearchParams{Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", Passengers: 1, Cabin: "ECONOMY", Currency: "USD"}
+	equivalent := SearchParams{
+		Origin:        " jfk",
+		Destination:   "lhr ",
+		DepartDate:    " 2026-11-10 ",
+		Cabin:         "economy",
+		Currency:      "usd",
+		FlexDays:      -2,
+		SortBy:        "duration",
+		ExcludeRedeye: true,
+		FlightNumbers: []string{"BA178"},
+	}
+	if SearchCacheKey(base) != SearchCacheKey(equivalent) {
+		t.Errorf("equivalent params have different keys:\n%s\n%s", SearchCacheKey(base), SearchCacheKey(equivalent))
+	}
+
+	for name, changed := range map[string]SearchParams{
+		"return date": {Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", ReturnDate: "2026-11-17", Cabin: "ECONOMY", Currency: "USD"},
+		"passengers":  {Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", Passengers: 2, Cabin: "ECONOMY", Currency: "USD"},
+		"non-stop":    {Origin: "JFK", Destination: "LHR", DepartDate: "2026-11-10", NonStop: true, Cabin: "ECONOMY", Currency: "USD"},
+	} {
+		if SearchCacheKey(changed) == SearchCacheKey(base) {
+			t.Errorf("%s does not change the key %s", name, SearchCacheKey(base))
+		}
+	}
+}
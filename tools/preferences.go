@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+var defaultAlliances = map[string][]string{
+	"star_alliance": {"A3", "AC", "AI", "AV", "BR", "CA", "CM", "ET", "LH", "LO", "LX", "MS", "NH", "NZ", "OS", "OZ", "SA", "SN", "SQ", "TG", "TK", "TP", "UA", "ZH"},
+	"oneworld":      {"AA", "AS", "AT", "AY", "BA", "CX", "FJ", "IB", "JL", "MH", "QF", "QR", "RJ", "UL", "WY"},
+	"skyteam":       {"AF", "AM", "AR", "AZ", "CI", "DL", "GA", "KE", "KL", "KQ", "ME", "MF", "MU", "OK", "RO", "SK", "SV", "UX", "VN"},
+}
+
+var defaultMileagePrograms = map[string][]string{
+	"aadvantage":     defaultAlliances["oneworld"],
+	"avios":          defaultAlliances["oneworld"],
+	"mileageplus":    defaultAlliances["star_alliance"],
+	"miles_and_more": defaultAlliances["star_alliance"],
+	"aeroplan":       defaultAlliances["star_alliance"],
+	"skymiles":       defaultAlliances["skyteam"],
+	"flying_blue":    defaultAlliances["skyteam"],
+}
+
+// alliances returns the alliance→carriers map, overridable with a JSON
+// object in FLIGHT_ALLIANCES.
+func alliances() map[string][]string {
+	return carrierGroups("FLIGHT_ALLIANCES", defaultAlliances)
+}
+
+// mileagePrograms returns the program→eligible carriers map, overridable
+// with a JSON object in FLIGHT_MILEAGE_PROGRAMS.
+func mileagePrograms() map[string][]string {
+	return carrierGroups("FLIGHT_MILEAGE_PROGRAMS", defaultMileagePrograms)
+}
+
+func carrierGroups(envKey string, defaults map[string][]string) map[string][]string {
+	groups := defaults
+	if value := os.Getenv(envKey); value != "" {
+		var custom map[string][]string
+		if err := json.Unmarshal([]byte(value), &custom); err == nil {
+			groups = custom
+		}
+	}
+
+	normalized := make(map[string][]string, len(groups))
+	for name, carriers := range groups {
+		normalized[normalizeGroupName(name)] = carriers
+	}
+	return normalized
+}
+
+func normalizeGroupName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func carrierSet(carriers []string) map[string]bool {
+	set := make(map[string]bool, len(carriers))
+	for _, carrier := range carriers {
+		set[strings.ToUpper(strings.TrimSpace(carrier))] = true
+	}
+	return set
+}
+
+// flownWithin reports whether every segment is marketed or operated by a
+// carrier in the set.
+func flownWithin(offer FlightOffer, set map[string]bool) bool {
+	if len(offer.Segments) == 0 {
+		return set[offer.Airline]
+	}
+	for _, segment := range offer.Segments {
+		if !set[segment.Carrier] && !set[segment.OperatingCarrier] {
+			return false
+		}
+	}
+	return true
+}
+
+// preferenceCarriers resolves mileage_program and preferred_alliance to
+// their carrier sets, nil when unset. validateSearchParams calls it so an
+// unknown name is rejected before any Amadeus request is made.
+func preferenceCarriers(params SearchParams) (programCarriers, allianceCarriers map[string]bool, err error) {
+	if params.MileageProgram != "" {
+		carriers, ok := mileagePrograms()[normalizeGroupName(params.MileageProgram)]
+		if !ok {
+			return nil, nil, &ArgumentError{Arg: "mileage_program", Msg: fmt.Sprintf("unknown program %q", params.MileageProgram)}
+		}
+		programCarriers = carrierSet(carriers)
+	}
+	if params.PreferredAlliance != "" {
+		carriers, ok := alliances()[normalizeGroupName(params.PreferredAlliance)]
+		if !ok {
+			return nil, nil, &ArgumentError{Arg: "preferred_alliance", Msg: fmt.Sprintf("unknown alliance %q", params.PreferredAlliance)}
+		}
+		allianceCarriers = carrierSet(carriers)
+	}
+	return programCarriers, allianceCarriers, nil
+}
+
+// applyPreferences drops offers that fail mileage_only and moves offers
+// matching mileage_program and preferred_alliance ahead of the rest. Each
+// matching preference adds one boost; ties keep their original order.
+func applyPreferences(offers []FlightOffer, params SearchParams) ([]FlightOffer, error) {
+	programCarriers, allianceCarriers, err := preferenceCarriers(params)
+	if err != nil {
+		return nil, err
+	}
+	if programCarriers == nil && allianceCarriers == nil {
+		return offers, nil
+	}
+
+	boosts := make(map[int]int, len(offers))
+	kept := make([]FlightOffer, 0, len(offers))
+	for _, offer := range offers {
+		boost := 0
+		if programCarriers != nil {
+			if flownWithin(offer, programCarriers) {
+				boost++
+			} else if params.MileageOnly {
+				continue
+			}
+		}
+		if allianceCarriers != nil && flownWithin(offer, allianceCarriers) {
+			boost++
+		}
+		boosts[len(kept)] = boost
+		kept = append(kept, offer)
+	}
+
+	order := make([]int, len(kept))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return boosts[order[a]] > boosts[order[b]] })
+
+	ranked := make([]FlightOffer, len(kept))
+	for i, idx := range order {
+		ranked[i] = kept[idx]
+	}
+	return ranked, nil
+}
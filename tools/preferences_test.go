@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func mileageTestOffers() []FlightOffer {
+	return []FlightOffer{
+		{OfferID: "dl", Segments: []Segment{{Carrier: "DL"}}},
+		{OfferID: "ua", Segments: []Segment{{Carrier: "UA"}, {Carrier: "LH"}}},
+		{OfferID: "mixed", Segments: []Segment{{Carrier: "UA"}, {Carrier: "BA"}}},
+	}
+}
+
+func TestMileageProgramBoostsEligibleOffers(t *testing.T) {
+	offers, err := applyPreferences(mileageTestOffers(), SearchParams{MileageProgram: "Miles and More"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 3 {
+		t.Fatalf("got %d offers, want all 3 kept", len(offers))
+	}
+	if got := []string{offers[0].OfferID, offers[1].OfferID, offers[2].OfferID}; got[0] != "ua" || got[1] != "dl" || got[2] != "mixed" {
+		t.Errorf("order = %v, want [ua dl mixed]", got)
+	}
+}
+
+func TestMileageOnlyDropsIneligibleOffers(t *testing.T) {
+	offers, err := applyPreferences(mileageTestOffers(), SearchParams{MileageProgram: "mileageplus", MileageOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 1 || offers[0].OfferID != "ua" {
+		t.Errorf("offers = %v, want only ua", offers)
+	}
+}
+
+func TestUnknownPreferencesRejectedBeforeSearching(t *testing.T) {
+	fakeAmadeus(t, "token", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request sent for an invalid search: %s", r.URL)
+	})
+
+	for _, params := range []SearchParams{
+		{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01", MileageProgram: "nope"},
+		{Origin: "JFK", Destination: "BOS", DepartDate: "2026-01-01", PreferredAlliance: "nope"},
+	} {
+		if _, err := searchFlights(context.Background(), params); ErrorCode(err) != ErrorCodeInvalidArgument {
+			t.Errorf("%+v: err = %v, want invalid argument", params, err)
+		}
+	}
+	if valid, _ := TokenStatus(); valid {
+		t.Error("a token was fetched for an invalid search")
+	}
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type offerPricer interface {
+	priceOffer(ctx context.Context, raw json.RawMessage) (price, currency string, err error)
+}
+
+// PriceOffer confirms the current fare of an offer returned by a search in
+// this process with the Amadeus flight offers pricing API. The returned
+// copy carries the confirmed price and PriceGuaranteed set; it is not yet
+// booked, so the price can still change before ticketing.
+func PriceOffer(ctx context.Context, offer FlightOffer) (FlightOffer, error) {
+	if len(offer.raw) == 0 {
+		return offer, &ArgumentError{Arg: "offer", Msg: "not returned by a search in this process; search again before pricing"}
+	}
+
+	run, err := newSearchRun(ctx)
+	if err != nil {
+		return offer, err
+	}
+	pricer, ok := run.fetcher.(offerPricer)
+	if !ok {
+		return offer, fmt.Errorf("%s results cannot be priced", run.source)
+	}
+
+	price, currency, err := pricer.priceOffer(ctx, offer.raw)
+	if err != nil {
+		return offer, err
+	}
+	offer.Price = price
+	offer.Currency = currency
+	offer.PriceGuaranteed = true
+	return offer, nil
+}
+
+func (c *amadeusClient) priceOffer(ctx context.Context, raw json.RawMessage) (string, string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			"type":         "flight-offers-pricing",
+			"flightOffers": []json.RawMessage{raw},
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	body, err := c.send(ctx, "flight offers pricing", func(token string) (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/shopping/flight-offers/pricing", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept-Encoding", "gzip")
+		return request, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return parsePricedOffer(body)
+}
+
+func parsePricedOffer(body []byte) (string, string, error) {
+	var priced struct {
+		Data struct {
+			FlightOffers []struct {
+				Price struct {
+					Total    string `json:"total"`
+					Currency string `json:"currency"`
+				} `json:"price"`
+			} `json:"flightOffers"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &priced); err != nil {
+		return "", "", err
+	}
+	if len(priced.Data.FlightOffers) == 0 || priced.Data.FlightOffers[0].Price.Total == "" {
+		return "", "", fmt.Errorf("amadeus pricing response missing flight offer price")
+	}
+	offer := priced.Data.FlightOffers[0]
+	return offer.Price.Total, offer.Price.Currency, nil
+}
+
+// priceOffer confirms mock offers at their search price.
+func (mockFetcher) priceOffer(ctx context.Context, raw json.RawMessage) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	var offer struct {
+		Price struct {
+			Total    string `json:"total"`
+			Currency string `json:"currency"`
+		} `json:"price"`
+	}
+	if err := json.Unmarshal(raw, &offer); err != nil {
+		return "", "", err
+	}
+	return offer.Price.Total, offer.Price.Currency, nil
+}
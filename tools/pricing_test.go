@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSearchOffersAreNotPriceGuaranteed(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{Origin: "JFK", Destination: "LAX", DepartDate: "2026-12-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) == 0 {
+		t.Fatal("no offers")
+	}
+	for _, offer := range outcome.Offers {
+		body, err := json.Marshal(offer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), `"price_guaranteed":false`) {
+			t.Fatalf("search offer %s: %s, want price_guaranteed:false", offer.Ref, body)
+		}
+	}
+
+	priced, err := PriceOffer(context.Background(), outcome.Offers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priced.PriceGuaranteed || priced.Price != outcome.Offers[0].Price {
+		t.Errorf("priced offer = %s %v, want %s guaranteed", priced.Price, priced.PriceGuaranteed, outcome.Offers[0].Price)
+	}
+}
+
+func TestParseAmadeusOffersKeepsEachRawOffer(t *testing.T) {
+	body := []byte(`{"data":[
+		{"id":"1","price":{"total":"100.00","currency":"EUR"},"itineraries":[{"segments":[{"carrierCode":"LH","number":"400","departure":{"iataCode":"FRA","at":"2026-11-10T10:00:00"},"arrival":{"iataCode":"JFK","at":"2026-11-10T13:00:00"}}]}]},
+		{"id":"2","price":{"total":"200.00","currency":"EUR"},"itineraries":[{"segments":[{"carrierCode":"UA","number":"960","departure":{"iataCode":"FRA","at":"2026-11-10T12:00:00"},"arrival":{"iataCode":"JFK","at":"2026-11-10T15:00:00"}}]}]}
+	]}`)
+
+	offers, err := parseAmadeusOffers(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 2 {
+		t.Fatalf("got %d offers, want 2", len(offers))
+	}
+	for _, offer := range offers {
+		var raw struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(offer.raw, &raw); err != nil || raw.ID != offer.AmadeusOfferID {
+			t.Errorf("offer %s kept raw offer %q (%v)", offer.AmadeusOfferID, raw.ID, err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	sortByPrice    = "price"
+	sortByDuration = "duration"
+	sortByStops    = "stops"
+	sortByValue    = "value"
+)
+
+func normalizeSortBy(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case sortByDuration:
+		return sortByDuration
+	case sortByStops:
+		return sortByStops
+	case sortByValue:
+		return sortByValue
+	default:
+		return sortByPrice
+	}
+}
+
+// sortOffers orders offers by sortBy, breaking ties by price. Sorting by
+// value scores the offers first and leaves ScoreDetail set.
+func sortOffers(offers []FlightOffer, sortBy string) {
+	if sortBy == sortByValue {
+		scoreOffers(offers, loadValueWeights())
+	}
+	price := func(offer FlightOffer) float64 {
+		if p, ok := parsePrice(offer.Price); ok {
+			return p
+		}
+		return math.MaxFloat64
+	}
+	duration := func(offer FlightOffer) int {
+		if d, ok := parseISODuration(offer.Duration); ok {
+			return d
+		}
+		return math.MaxInt32
+	}
+
+	sort.SliceStable(offers, func(i, j int) bool {
+		a, b := offers[i], offers[j]
+		switch sortBy {
+		case sortByDuration:
+			if duration(a) != duration(b) {
+				return duration(a) < duration(b)
+			}
+		case sortByStops:
+			if a.Stops != b.Stops {
+				return a.Stops < b.Stops
+			}
+		case sortByValue:
+			if a.ScoreDetail.Score != b.ScoreDetail.Score {
+				return a.ScoreDetail.Score > b.ScoreDetail.Score
+			}
+		}
+		return price(a) < price(b)
+	})
+}
+
+// rankingReason explains why offers[0] came first, derived from the sort,
+// preferences and filters that were active for this search.
+func rankingReason(top FlightOffer, params SearchParams) string {
+	var reason string
+	switch normalizeSortBy(params.SortBy) {
+	case sortByDuration:
+		reason = "shortest offer"
+	case sortByStops:
+		reason = "fewest-stops offer"
+	case sortByValue:
+		reason = "best-value offer"
+	default:
+		reason = "cheapest offer"
+	}
+
+	if top.Daypart != "" {
+		reason = strings.TrimSuffix(reason, "offer") + top.Daypart + " offer"
+	}
+
+	var among []string
+	if params.MileageProgram != "" {
+		if carriers, ok := mileagePrograms()[normalizeGroupName(params.MileageProgram)]; ok && flownWithin(top, carrierSet(carriers)) {
+			among = append(among, "earning on "+params.MileageProgram)
+		}
+	}
+	if params.PreferredAlliance != "" {
+		if carriers, ok := alliances()[normalizeGroupName(params.PreferredAlliance)]; ok && flownWithin(top, carrierSet(carriers)) {
+			among = append(among, "flown on "+params.PreferredAlliance)
+		}
+	}
+	if len(among) > 0 {
+		reason += " among those " + strings.Join(among, " and ")
+	}
+
+	switch top.Stops {
+	case 0:
+		reason += ", nonstop"
+	case 1:
+		reason += ", 1 stop"
+	default:
+		reason += fmt.Sprintf(", %d stops", top.Stops)
+	}
+
+	if params.FlexDays > 0 {
+		reason += fmt.Sprintf(", within ±%d days of the requested dates", params.FlexDays)
+	} else {
+		reason += ", on the requested dates"
+	}
+	if params.MaxPrice > 0 {
+		reason += fmt.Sprintf(", under max_price %0.0f %s", params.MaxPrice, params.Currency)
+	}
+
+	return strings.TrimSpace(reason)
+}
+
+var dayparts = []struct {
+	name       string
+	start, end int
+}{
+	{"night", 0, 6 * 60},
+	{"morning", 6 * 60, 12 * 60},
+	{"afternoon", 12 * 60, 18 * 60},
+	{"evening", 18 * 60, 24 * 60},
+}
+
+func daypartOf(offer FlightOffer) string {
+	if len(offer.Segments) == 0 {
+		return ""
+	}
+	depart, ok := parseLocalTime(offer.Segments[0].DepartAt)
+	if !ok {
+		return ""
+	}
+	minute := minuteOfDay(depart)
+	for _, part := range dayparts {
+		if minute >= part.start && minute < part.end {
+			return part.name
+		}
+	}
+	return ""
+}
+
+// bestPerDaypart keeps the first offer of the already ranked list in each
+// daypart of the origin-local departure time, returned night, morning,
+// afternoon, evening. Empty dayparts are skipped.
+func bestPerDaypart(ranked []FlightOffer) []FlightOffer {
+	best := map[string]FlightOffer{}
+	for _, offer := range ranked {
+		part := daypartOf(offer)
+		if part == "" {
+			continue
+		}
+		if _, ok := best[part]; !ok {
+			offer.Daypart = part
+			best[part] = offer
+		}
+	}
+
+	picked := make([]FlightOffer, 0, len(best))
+	for _, part := range dayparts {
+		if offer, ok := best[part.name]; ok {
+			picked = append(picked, offer)
+		}
+	}
+	return picked
+}
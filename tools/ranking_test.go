@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRankingReasonForPriceSort(t *testing.T) {
+	t.Setenv("AMADEUS_MOCK", "true")
+
+	outcome, err := searchFlights(context.Background(), SearchParams{
+		Origin:      "SFO",
+		Destination: "JFK",
+		DepartDate:  "2026-03-15",
+		SortBy:      "price",
+		MaxPrice:    5000,
+		Currency:    "USD",
+		Explain:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Offers) < 2 {
+		t.Fatalf("got %d offers, want at least 2", len(outcome.Offers))
+	}
+
+	want := "cheapest offer, nonstop, on the requested dates, under max_price 5000 USD"
+	if got := outcome.Offers[0].RankingReason; got != want {
+		t.Errorf("ranking_reason = %q, want %q", got, want)
+	}
+	for _, offer := range outcome.Offers[1:] {
+		if offer.RankingReason != "" {
+			t.Errorf("offer %s has ranking_reason %q, want it on the top offer only", offer.Ref, offer.RankingReason)
+		}
+	}
+}
+
+func TestBestPerDaypartKeepsOnePerPopulatedDaypart(t *testing.T) {
+	ranked := []FlightOffer{
+		departingAt("evening-best", "19:30"),
+		departingAt("morning-best", "08:00"),
+		departingAt("evening-other", "20:00"),
+		departingAt("morning-other", "09:00"),
+		departingAt("afternoon", "13:00"),
+	}
+
+	picked := bestPerDaypart(ranked)
+	want := [][2]string{{"morning-best", "morning"}, {"afternoon", "afternoon"}, {"evening-best", "evening"}}
+	if len(picked) != len(want) {
+		t.Fatalf("picked %d offers, want %d", len(picked), len(want))
+	}
+	for i, offer := range picked {
+		if offer.OfferID != want[i][0] || offer.Daypart != want[i][1] {
+			t.Errorf("pick %d = %s (%s), want %s (%s)", i, offer.OfferID, offer.Daypart, want[i][0], want[i][1])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+)
+
+var defaultValueWeights = ValueWeights{Price: 0.6, Duration: 0.3, Stops: 0.1}
+
+// ValueWeights are the relative weights of the sort_by "value" sub-scores.
+type ValueWeights struct {
+	Price    float64 `json:"price"`
+	Duration float64 `json:"duration"`
+	Stops    float64 `json:"stops"`
+}
+
+// ScoreDetail breaks down a sort_by "value" ranking. Each sub-score is
+// normalized across the result set, 1 for the best offer and 0 for the
+// worst; Score is their weighted sum.
+type ScoreDetail struct {
+	Price    float64      `json:"price"`
+	Duration float64      `json:"duration"`
+	Stops    float64      `json:"stops"`
+	Weights  ValueWeights `json:"weights"`
+	Score    float64      `json:"score"`
+}
+
+// loadValueWeights reads FLIGHT_VALUE_WEIGHTS, a JSON object with price,
+// duration and stops weights. Weights are scaled to sum to 1; negative or
+// all-zero weights fall back to the defaults.
+func loadValueWeights() ValueWeights {
+	weights := defaultValueWeights
+	if value := os.Getenv("FLIGHT_VALUE_WEIGHTS"); value != "" {
+		var configured ValueWeights
+		if err := json.Unmarshal([]byte(value), &configured); err != nil {
+			logf("ignoring malformed FLIGHT_VALUE_WEIGHTS: %v", err)
+		} else {
+			weights = configured
+		}
+	}
+
+	total := weights.Price + weights.Duration + weights.Stops
+	if weights.Price < 0 || weights.Duration < 0 || weights.Stops < 0 || total <= 0 {
+		logf("invalid FLIGHT_VALUE_WEIGHTS; using defaults")
+		weights = defaultValueWeights
+		total = weights.Price + weights.Duration + weights.Stops
+	}
+	return ValueWeights{Price: weights.Price / total, Duration: weights.Duration / total, Stops: weights.Stops / total}
+}
+
+// scoreOffers sets ScoreDetail on every offer. Unparseable prices and
+// durations score 0.
+func scoreOffers(offers []FlightOffer, weights ValueWeights) {
+	prices := make([]float64, len(offers))
+	durations := make([]float64, len(offers))
+	stops := make([]float64, len(offers))
+	for i, offer := range offers {
+		prices[i], durations[i] = math.NaN(), math.NaN()
+		if price, ok := parsePrice(offer.Price); ok {
+			prices[i] = price
+		}
+		if minutes, ok := parseISODuration(offer.Duration); ok {
+			durations[i] = float64(minutes)
+		}
+		stops[i] = float64(offer.Stops)
+	}
+
+	priceScores := normalizedScores(prices)
+	durationScores := normalizedScores(durations)
+	stopScores := normalizedScores(stops)
+	for i := range offers {
+		detail := ScoreDetail{
+			Price:    priceScores[i],
+			Duration: durationScores[i],
+			Stops:    stopScores[i],
+			Weights:  weights,
+		}
+		detail.Score = roundScore(weights.Price*detail.Price + weights.Duration*detail.Duration + weights.Stops*detail.Stops)
+		offers[i].ScoreDetail = &detail
+	}
+}
+
+// normalizedScores maps lower-is-better values onto 1 (lowest) to 0
+// (highest). NaN values score 0; when all values are equal they score 1.
+func normalizedScores(values []float64) []float64 {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+	}
+
+	scores := make([]float64, len(values))
+	for i, value := range values {
+		switch {
+		case math.IsNaN(value):
+			scores[i] = 0
+		case high == low:
+			scores[i] = 1
+		default:
+			scores[i] = roundScore((high - value) / (high - low))
+		}
+	}
+	return scores
+}
+
+func roundScore(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
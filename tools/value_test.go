@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreDetailSumsToScore(t *testing.T) {
+	t.Setenv("FLIGHT_VALUE_WEIGHTS", `{"price":1,"duration":2,"stops":1}`)
+	offers := []FlightOffer{
+		{OfferID: "cheap-slow", Price: "100.00", Duration: "PT10H", Stops: 2},
+		{OfferID: "fast", Price: "200.00", Duration: "PT5H", Stops: 0},
+		{OfferID: "middle", Price: "150.00", Duration: "PT7H30M", Stops: 1},
+	}
+
+	sortOffers(offers, normalizeSortBy("value"))
+	if offers[0].OfferID != "fast" {
+		t.Errorf("top offer = %s, want fast with duration weighted double", offers[0].OfferID)
+	}
+	for _, offer := range offers {
+		detail := offer.ScoreDetail
+		if detail == nil {
+			t.Fatalf("offer %s has no score_detail", offer.OfferID)
+		}
+		if detail.Weights != (ValueWeights{Price: 0.25, Duration: 0.5, Stops: 0.25}) {
+			t.Errorf("weights = %+v, want them scaled to sum to 1", detail.Weights)
+		}
+		sum := detail.Weights.Price*detail.Price + detail.Weights.Duration*detail.Duration + detail.Weights.Stops*detail.Stops
+		if math.Abs(sum-detail.Score) > 0.001 {
+			t.Errorf("offer %s: sub-scores times weights = %.4f, score = %.4f", offer.OfferID, sum, detail.Score)
+		}
+	}
+}